@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"pomegranate/database"
+	"pomegranate/manager"
+	"pomegranate/models"
+)
+
+// runCommand runs a maintenance command instead of starting the server
+func runCommand(name string, args []string) error {
+	switch name {
+	case "reindex":
+		return reindexCommand()
+	case "backup":
+		return backupCommand(args)
+	case "restore":
+		return restoreCommand(args)
+	case "export":
+		return exportCommand(args)
+	case "import":
+		return importCommand(args)
+	default:
+		return fmt.Errorf("unknown command %q. Available commands: reindex, backup, restore, export, import", name)
+	}
+}
+
+// openCommandDatabase opens the database for a command. When the running server holds it, the error
+// points to the api route doing the same, if any.
+func openCommandDatabase(route string) (*database.DB, error) {
+	db, err := openDatabase()
+	if errors.Is(err, database.ErrInUse) {
+		if route == "" {
+			return nil, fmt.Errorf("database is in use; stop the server first")
+		}
+		return nil, fmt.Errorf("database is in use; use %s", route)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("openDatabase: %w", err)
+	}
+
+	return db, nil
+}
+
+// reindexCommand rebuilds every secondary index of the database
+func reindexCommand() error {
+	db, err := openCommandDatabase("")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// creating the manager declares the indexes
+	if _, err := manager.NewManager(db); err != nil {
+		return fmt.Errorf("manager.NewManager: %w", err)
+	}
+
+	for _, bucket := range db.IndexedBuckets() {
+		if err := db.RebuildIndexes(bucket); err != nil {
+			return fmt.Errorf("db.RebuildIndexes (%s): %w", bucket, err)
+		}
+		fmt.Printf("Rebuilt indexes of %s\n", bucket)
+	}
+
+	return nil
+}
+
+// backupCommand writes a backup into the given folder, or the backup folder, applying the retention
+func backupCommand(args []string) error {
+	dir := backupDir()
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	retention, err := backupRetention()
+	if err != nil {
+		return err
+	}
+
+	db, err := openCommandDatabase("GET /api/v1/backup")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	name, err := db.Backup(dir, retention)
+	if err != nil {
+		return fmt.Errorf("db.Backup: %w", err)
+	}
+	fmt.Printf("Database backed up to %s\n", name)
+
+	return nil
+}
+
+// restoreCommand replaces the database with a backup. The server must be stopped.
+func restoreCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: restore <backup file>")
+	}
+
+	if err := database.Restore(databasePath(), args[0]); err != nil {
+		return fmt.Errorf("database.Restore: %w", err)
+	}
+	fmt.Printf("Database restored from %s\n", args[0])
+
+	return nil
+}
+
+// exportCommand writes the library as json to the given file, or to the standard output
+func exportCommand(args []string) error {
+	db, err := openCommandDatabase("GET /api/v1/export")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := manager.NewManager(db)
+	if err != nil {
+		return fmt.Errorf("manager.NewManager: %w", err)
+	}
+	doc, err := m.Export()
+	if err != nil {
+		return fmt.Errorf("manager.Export: %w", err)
+	}
+
+	out := os.Stdout
+	if len(args) > 0 && args[0] != "-" {
+		out, err = os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("os.Create: %w", err)
+		}
+		defer out.Close()
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("encoder.Encode: %w", err)
+	}
+
+	return nil
+}
+
+// importCommand imports a json export: import [-replace] [-dry-run] <file>
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	replace := flags.Bool("replace", false, "delete the records missing from the document")
+	dryRun := flags.Bool("dry-run", false, "report the changes without writing them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: import [-replace] [-dry-run] <file>")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+	var doc manager.ExportDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+
+	options := manager.ImportOptions{Mode: manager.ImportMerge, DryRun: *dryRun}
+	if *replace {
+		options.Mode = manager.ImportReplace
+	}
+
+	db, err := openCommandDatabase("POST /api/v1/import")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m, err := manager.NewManager(db)
+	if err != nil {
+		return fmt.Errorf("manager.NewManager: %w", err)
+	}
+	report, err := m.Import(doc, options)
+	if err != nil {
+		return fmt.Errorf("manager.Import: %w", err)
+	}
+
+	if report.DryRun {
+		fmt.Println("Dry run, nothing was written")
+	}
+	for _, bucket := range []string{models.MovieKind, models.ProfileKind, models.BlocklistKind, models.PendingImportKind, models.HistoryKind, models.SettingsKind} {
+		changes := report.Changes[bucket]
+		fmt.Printf("%s: %d created, %d updated, %d deleted, %d unchanged\n", bucket, len(changes.Created), len(changes.Updated), len(changes.Deleted), changes.Unchanged)
+	}
+
+	return nil
+}
@@ -8,13 +8,19 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strconv"
 	"syscall"
 	"time"
 
 	"pomegranate/database"
+	"pomegranate/downloader"
+	"pomegranate/importer"
 	"pomegranate/manager"
+	"pomegranate/metrics"
 	"pomegranate/newznab"
+	"pomegranate/nzbget"
 	"pomegranate/sabnzbd"
+	"pomegranate/scheduler"
 	"pomegranate/service"
 	"pomegranate/themoviedb"
 )
@@ -25,7 +31,26 @@ const (
 	sabnzbdHostEnvironmentKey      = "SABNZBD_HOST"
 	newznabEnvironmentPrefix       = "NEWZNAB"
 	sabnzbdApiKeyEnvironmentKey    = "SABNZBD_API_KEY"
+	nzbgetHostEnvironmentKey       = "NZBGET_HOST"
+	nzbgetUsernameEnvironmentKey   = "NZBGET_USERNAME"
+	nzbgetPasswordEnvironmentKey   = "NZBGET_PASSWORD"
+	downloaderEnvironmentKey       = "DOWNLOADER"
+	deleteFailedKey                = "DOWNLOADER_DELETE_FAILED"
 	databaseDirKey                 = "DATA_DIR"
+	searchIntervalKey              = "SEARCH_INTERVAL"
+	monitorIntervalKey             = "MONITOR_INTERVAL"
+	libraryDirKey                  = "LIBRARY_DIR"
+	importModeKey                  = "IMPORT_MODE"
+	namingTemplateKey              = "NAMING_TEMPLATE"
+	backupDirKey                   = "BACKUP_DIR"
+	backupIntervalKey              = "BACKUP_INTERVAL"
+	backupRetentionKey             = "BACKUP_RETENTION"
+	indexerTimeoutKey              = "INDEXER_TIMEOUT"
+
+	defaultSearchInterval  = 12 * time.Hour
+	defaultMonitorInterval = time.Minute
+	defaultBackupInterval  = 24 * time.Hour
+	defaultBackupRetention = 7
 )
 
 type Logger struct{}
@@ -34,12 +59,46 @@ func (l Logger) Log(service string, format string, a ...interface{}) {
 	fmt.Printf("[%s] %s", service, fmt.Sprintf(format, a...))
 }
 
+// databasePath is the database file inside DATA_DIR
+func databasePath() string {
+	return path.Join(os.Getenv(databaseDirKey), "pomegranate.db")
+}
+
+func openDatabase() (*database.DB, error) {
+	return database.Open(databasePath())
+}
+
+// backupDir is BACKUP_DIR, or the backups folder inside DATA_DIR
+func backupDir() string {
+	if dir := os.Getenv(backupDirKey); dir != "" {
+		return dir
+	}
+
+	return path.Join(os.Getenv(databaseDirKey), "backups")
+}
+
+// backupRetention is the number of scheduled backups kept, 0 keeping all of them
+func backupRetention() (int, error) {
+	value := os.Getenv(backupRetentionKey)
+	if value == "" {
+		return defaultBackupRetention, nil
+	}
+
+	retention, err := strconv.Atoi(value)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid value for %s: %s", backupRetentionKey, value)
+	}
+
+	return retention, nil
+}
+
 func loadSettings() (config service.Config, err error) {
 	themoviedbApiKey := os.Getenv(themoviedbApiKeyEnvironmentKey)
 	if themoviedbApiKey == "" {
 		return config, fmt.Errorf("invalid or missing required environment key: %s", themoviedbApiKeyEnvironmentKey)
 	}
 	config.Tmdb = themoviedb.New(themoviedbApiKey)
+	config.Tmdb.Client = metrics.Client("themoviedb")
 
 	for i := 1; true; i++ {
 		newznabHostKey := fmt.Sprintf("%s_HOST_%d", newznabEnvironmentPrefix, i)
@@ -52,35 +111,189 @@ func loadSettings() (config service.Config, err error) {
 			break
 		}
 
-		config.Newz = append(config.Newz, newznab.Newznab{Host: host, ApiKey: apiKey})
+		indexer := newznab.Newznab{Host: host, ApiKey: apiKey, Client: metrics.Client("newznab")}
+		for _, limit := range []struct {
+			key   string
+			value *int
+		}{
+			{fmt.Sprintf("%s_API_LIMIT_%d", newznabEnvironmentPrefix, i), &indexer.ApiLimit},
+			{fmt.Sprintf("%s_GRAB_LIMIT_%d", newznabEnvironmentPrefix, i), &indexer.GrabLimit},
+		} {
+			value := os.Getenv(limit.key)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return config, fmt.Errorf("invalid value for %s: %s", limit.key, value)
+			}
+			*limit.value = n
+		}
+
+		config.Newz = append(config.Newz, indexer)
 	}
 	if len(config.Newz) <= 0 {
 		return config, fmt.Errorf("invalid or missing newznab environemnt keys. Use keys %s_HOST_1 and %s_KEY_1 for setting the sources of nzb files. Numbers should be sequential and start at 1. Key is optional if the server does not require one", newznabEnvironmentPrefix, newznabEnvironmentPrefix)
 	}
 
-	dbPath := os.Getenv(databaseDirKey)
-	db, err := database.Open(path.Join(dbPath, "pomegranate.db"))
+	db, err := openDatabase()
 	if err != nil {
-		log.Fatal(fmt.Errorf("database.Open: %w", err))
+		log.Fatal(fmt.Errorf("openDatabase: %w", err))
 	}
 	config.DB = db
 
-	sabnzbdHost := os.Getenv(sabnzbdHostEnvironmentKey)
-	if sabnzbdHost != "" {
-		sabnzbdKey := os.Getenv(sabnzbdApiKeyEnvironmentKey)
-		config.Sabnzbd = sabnzbd.New(sabnzbdHost, sabnzbdKey)
-		config.Sabnzbd.Logger = &Logger{}
+	config.Downloader, err = loadDownloader()
+	if err != nil {
+		return config, fmt.Errorf("loadDownloader: %w", err)
 	}
 
 	config.Manager, err = manager.NewManager(db)
 	if err != nil {
 		return config, fmt.Errorf("cannot create manager object: %w", err)
 	}
+	config.Manager.Indexers = config.Newz
+	config.Manager.IndexerTimeout, err = durationSetting(indexerTimeoutKey, manager.DefaultIndexerTimeout)
+	if err != nil {
+		return config, err
+	}
+	if err := metrics.RegisterMovieCounts(config.Manager.MovieCountsByStatus); err != nil {
+		return config, fmt.Errorf("metrics.RegisterMovieCounts: %w", err)
+	}
+	config.Manager.Downloader = config.Downloader
+	if value := os.Getenv(deleteFailedKey); value != "" {
+		config.Manager.DeleteFailed, err = strconv.ParseBool(value)
+		if err != nil {
+			return config, fmt.Errorf("invalid value for %s: %w", deleteFailedKey, err)
+		}
+	}
+
+	if libraryDir := os.Getenv(libraryDirKey); libraryDir != "" {
+		imp, err := importer.New(libraryDir, importer.Mode(os.Getenv(importModeKey)), os.Getenv(namingTemplateKey))
+		if err != nil {
+			return config, fmt.Errorf("importer.New: %w", err)
+		}
+		config.Manager.Importer = &imp
+	}
 
 	return
 }
 
+// loadDownloader picks the usenet client from the environment. The client can be chosen explicitly
+// with DOWNLOADER=sabnzbd or DOWNLOADER=nzbget; otherwise it is the one with a host set.
+// A nil downloader is returned if none is configured.
+func loadDownloader() (downloader.Downloader, error) {
+	sabnzbdHost := os.Getenv(sabnzbdHostEnvironmentKey)
+	nzbgetHost := os.Getenv(nzbgetHostEnvironmentKey)
+
+	name := os.Getenv(downloaderEnvironmentKey)
+	if name == "" {
+		switch {
+		case sabnzbdHost != "" && nzbgetHost != "":
+			return nil, fmt.Errorf("both %s and %s are set. Use %s to pick one of them", sabnzbdHostEnvironmentKey, nzbgetHostEnvironmentKey, downloaderEnvironmentKey)
+		case sabnzbdHost != "":
+			name = "sabnzbd"
+		case nzbgetHost != "":
+			name = "nzbget"
+		default:
+			return nil, nil
+		}
+	}
+
+	switch name {
+	case "sabnzbd":
+		if sabnzbdHost == "" {
+			return nil, fmt.Errorf("invalid or missing required environment key: %s", sabnzbdHostEnvironmentKey)
+		}
+		s := sabnzbd.New(sabnzbdHost, os.Getenv(sabnzbdApiKeyEnvironmentKey))
+		s.Logger = &Logger{}
+		s.Client = metrics.Client("sabnzbd")
+		return s, nil
+	case "nzbget":
+		if nzbgetHost == "" {
+			return nil, fmt.Errorf("invalid or missing required environment key: %s", nzbgetHostEnvironmentKey)
+		}
+		n := nzbget.New(nzbgetHost, os.Getenv(nzbgetUsernameEnvironmentKey), os.Getenv(nzbgetPasswordEnvironmentKey))
+		n.Logger = &Logger{}
+		n.Client = metrics.Client("nzbget")
+		return n, nil
+	}
+
+	return nil, fmt.Errorf("unknown downloader %q for %s. Use sabnzbd or nzbget", name, downloaderEnvironmentKey)
+}
+
+// durationSetting reads a duration (like 6h or 90m) from the environment, using fallback if not set
+func durationSetting(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration for %s: %w", key, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration for %s: must be positive", key)
+	}
+
+	return d, nil
+}
+
+func loadJobs(config service.Config) (*scheduler.Scheduler, error) {
+	jobs, err := scheduler.New(config.DB)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler.New: %w", err)
+	}
+	jobs.Logger = &Logger{}
+
+	searchInterval, err := durationSetting(searchIntervalKey, defaultSearchInterval)
+	if err != nil {
+		return nil, err
+	}
+	if err := jobs.Add(scheduler.Job{Name: "search-wanted", Interval: searchInterval, Run: config.Manager.SearchWanted}); err != nil {
+		return nil, fmt.Errorf("jobs.Add: %w", err)
+	}
+
+	monitorInterval, err := durationSetting(monitorIntervalKey, defaultMonitorInterval)
+	if err != nil {
+		return nil, err
+	}
+	if err := jobs.Add(scheduler.Job{Name: "monitor-downloads", Interval: monitorInterval, Run: config.Manager.MonitorDownloads}); err != nil {
+		return nil, fmt.Errorf("jobs.Add: %w", err)
+	}
+
+	backupInterval, err := durationSetting(backupIntervalKey, defaultBackupInterval)
+	if err != nil {
+		return nil, err
+	}
+	retention, err := backupRetention()
+	if err != nil {
+		return nil, err
+	}
+	dir := backupDir()
+	backup := func(ctx context.Context) error {
+		name, err := config.DB.Backup(dir, retention)
+		if err != nil {
+			return fmt.Errorf("db.Backup: %w", err)
+		}
+		log.Printf("database backed up to %s\n", name)
+		return nil
+	}
+	if err := jobs.Add(scheduler.Job{Name: "backup", Interval: backupInterval, Run: backup}); err != nil {
+		return nil, fmt.Errorf("jobs.Add: %w", err)
+	}
+
+	return jobs, nil
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fmt.Println("Pomegranate is initializing...")
 
 	config, err := loadSettings()
@@ -106,21 +319,29 @@ func main() {
 		fmt.Println(movie)
 	}
 
-	if config.Sabnzbd.Host != "" {
-		fmt.Println("Checking sabnzbd config...")
-		queue, err := config.Sabnzbd.Queue(sabnzbd.QueueRequestParams{})
+	if config.Downloader != nil {
+		fmt.Printf("Checking %s config...\n", config.Downloader.Name())
+		queue, err := config.Downloader.QueueJobs()
 		if err != nil {
-			fmt.Printf("Sabnzbd not configured properly: %s\n", err)
+			fmt.Printf("%s not configured properly: %s\n", config.Downloader.Name(), err)
 		} else {
 			fmt.Println(queue)
 		}
 	}
 
+	jobs, err := loadJobs(config)
+	if err != nil {
+		log.Fatal(fmt.Errorf("loadJobs: %w", err))
+	}
+
 	addr := fmt.Sprintf(":%d", defaultPort)
 	server := &http.Server{Addr: addr, Handler: service.Service(config)}
+	// event streams never end on their own, they are closed for the graceful shutdown to complete
+	server.RegisterOnShutdown(config.Manager.Events.Close)
 	serverCtx, serverStopCtx := context.WithCancel(context.Background())
 
-	signalListener(server, serverCtx, serverStopCtx)
+	jobs.Start(serverCtx)
+	signalListener(server, jobs, serverCtx, serverStopCtx)
 
 	fmt.Printf("Listening on %s\n", addr)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -130,7 +351,7 @@ func main() {
 	<-serverCtx.Done()
 }
 
-func signalListener(server *http.Server, serverCtx context.Context, serverStopCtx context.CancelFunc) {
+func signalListener(server *http.Server, jobs *scheduler.Scheduler, serverCtx context.Context, serverStopCtx context.CancelFunc) {
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	go func() {
@@ -152,6 +373,10 @@ func signalListener(server *http.Server, serverCtx context.Context, serverStopCt
 		if err != nil {
 			log.Fatal(err)
 		}
+
+		// Wait for running background jobs
+		jobs.Stop()
+
 		serverStopCtx()
 	}()
 }
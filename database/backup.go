@@ -0,0 +1,217 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	backupPrefix    = "pomegranate-"
+	backupExtension = ".db"
+)
+
+// WriteBackup writes a consistent copy of the database to w. The copy is made from a read transaction,
+// so the database keeps accepting writes meanwhile. setSize, if given, is called with the size of the
+// copy before anything is written, which is handy to set a Content-Length header.
+func (db *DB) WriteBackup(w io.Writer, setSize func(size int64)) (int64, error) {
+	if db.Database == nil {
+		return 0, errors.New("database was not initialized")
+	}
+
+	var n int64
+	err := db.Database.View(func(tx *bolt.Tx) error {
+		if setSize != nil {
+			setSize(tx.Size())
+		}
+
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	if err != nil {
+		return n, errors.Wrap(err, "Database.View")
+	}
+
+	return n, nil
+}
+
+// Backup writes a copy of the database into dir, then removes the oldest backups of the directory so that
+// at most retention of them are kept. A retention of 0 keeps every backup.
+func (db *DB) Backup(dir string, retention int) (string, error) {
+	if retention < 0 {
+		return "", errors.New("retention cannot be negative")
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", errors.Wrap(err, "os.MkdirAll")
+	}
+
+	// written under a temporary name first, so an interrupted backup is never mistaken for a complete one
+	tmp, err := ioutil.TempFile(dir, ".backup-*")
+	if err != nil {
+		return "", errors.Wrap(err, "ioutil.TempFile")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := db.WriteBackup(tmp, nil); err != nil {
+		_ = tmp.Close()
+		return "", errors.Wrap(err, "db.WriteBackup")
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return "", errors.Wrap(err, "file.Sync")
+	}
+	if err := tmp.Close(); err != nil {
+		return "", errors.Wrap(err, "file.Close")
+	}
+
+	name := filepath.Join(dir, backupPrefix+time.Now().UTC().Format("20060102-150405.000000")+backupExtension)
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return "", errors.Wrap(err, "os.Rename")
+	}
+
+	if err := pruneBackups(dir, retention); err != nil {
+		return name, errors.Wrap(err, "pruneBackups")
+	}
+
+	return name, nil
+}
+
+// Backups lists the backups of a directory, oldest first
+func Backups(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadDir")
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExtension) {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+	// names hold the backup time, so they sort chronologically
+	sort.Strings(backups)
+
+	return backups, nil
+}
+
+func pruneBackups(dir string, retention int) error {
+	if retention == 0 {
+		return nil
+	}
+
+	backups, err := Backups(dir)
+	if err != nil {
+		return errors.Wrap(err, "Backups")
+	}
+
+	for len(backups) > retention {
+		if err := os.Remove(backups[0]); err != nil {
+			return errors.Wrap(err, "os.Remove")
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
+
+// ValidateBackup checks that a file is a sound bbolt database this binary can read, returning its schema version
+func ValidateBackup(path string) (int, error) {
+	// bolt.Open creates missing files and initializes empty ones, so those are rejected beforehand
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, errors.Wrap(err, "os.Stat")
+	}
+	if info.IsDir() || info.Size() == 0 {
+		return 0, errors.Errorf("%s is not a database", path)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return 0, errors.Wrap(err, "bolt.Open")
+	}
+	defer db.Close()
+
+	var version int
+	err = db.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return errors.Wrap(err, "tx.Check")
+		}
+
+		var err error
+		version, err = schemaVersion(tx)
+		return err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "db.View")
+	}
+
+	if version > SchemaVersion() {
+		return version, SchemaTooNewError{Version: version, Supported: SchemaVersion()}
+	}
+
+	return version, nil
+}
+
+// Restore replaces the database at path with a backup, after validating it. The database must not be open:
+// Restore fails if another process holds it. The replaced database is kept next to it, with a .pre-restore suffix.
+func Restore(path string, backup string) error {
+	if _, err := ValidateBackup(backup); err != nil {
+		return errors.Wrap(err, "ValidateBackup")
+	}
+
+	// holding the lock makes sure nothing is using the database while it is swapped
+	current, err := bolt.Open(path, 0660, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return errors.Wrap(err, "database is in use")
+	}
+	defer current.Close()
+
+	src, err := os.Open(backup)
+	if err != nil {
+		return errors.Wrap(err, "os.Open")
+	}
+	defer src.Close()
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".restore-*")
+	if err != nil {
+		return errors.Wrap(err, "ioutil.TempFile")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "io.Copy")
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "file.Sync")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "file.Close")
+	}
+	if err := os.Chmod(tmp.Name(), 0660); err != nil {
+		return errors.Wrap(err, "os.Chmod")
+	}
+
+	previous := fmt.Sprintf("%s.pre-restore-%s", path, time.Now().Format("20060102-150405"))
+	if err := os.Link(path, previous); err != nil {
+		return errors.Wrap(err, "os.Link")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "os.Rename")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBackupRestore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "database.db")
+	backupDir := filepath.Join(dir, "backups")
+
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	if err := db.Store(MovieBucketName, []byte("tt0133093"), []byte(`{"title":"The Matrix"}`)); err != nil {
+		t.Fatalf("db.Store: %s", err)
+	}
+
+	var backup string
+	for i := 0; i < 3; i++ {
+		backup, err = db.Backup(backupDir, 2)
+		if err != nil {
+			t.Fatalf("db.Backup: %s", err)
+		}
+	}
+	backups, err := Backups(backupDir)
+	if err != nil {
+		t.Fatalf("Backups: %s", err)
+	}
+	if len(backups) != 2 || backups[1] != backup {
+		t.Errorf("expected the 2 latest backups to be kept, got %v", backups)
+	}
+
+	if version, err := ValidateBackup(backup); err != nil || version != SchemaVersion() {
+		t.Errorf("ValidateBackup: version %d, %v", version, err)
+	}
+
+	if err := Restore(path, backup); err == nil {
+		t.Errorf("expected restoring an open database to fail")
+	}
+
+	if err := db.Delete(MovieBucketName, []byte("tt0133093")); err != nil {
+		t.Fatalf("db.Delete: %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("db.Close: %s", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.db")
+	if err := os.WriteFile(invalid, []byte("not a database"), 0600); err != nil {
+		t.Fatalf("os.WriteFile: %s", err)
+	}
+	if err := Restore(path, invalid); err == nil {
+		t.Errorf("expected restoring an invalid file to fail")
+	}
+
+	if err := Restore(path, backup); err != nil {
+		t.Fatalf("Restore: %s", err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer db.Close()
+	data, err := db.Read([]byte(MovieBucketName), []byte("tt0133093"))
+	if err != nil || data == nil {
+		t.Errorf("expected the restored movie, got %s (%v)", data, err)
+	}
+}
+
+func TestValidateBackupTooNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	err = db.Database.Update(func(tx *bolt.Tx) error {
+		return setSchemaVersion(tx, SchemaVersion()+1)
+	})
+	if err != nil {
+		t.Fatalf("setSchemaVersion: %s", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("db.Close: %s", err)
+	}
+
+	var tooNew SchemaTooNewError
+	if _, err := ValidateBackup(path); !errors.As(err, &tooNew) {
+		t.Errorf("expected SchemaTooNewError, got %v", err)
+	}
+}
@@ -2,40 +2,64 @@ package database
 
 import (
 	"context"
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 )
 
 const MovieBucketName = "movies"
 
+// ErrInUse is returned by Open when another process, like the running server, holds the database
+var ErrInUse = errors.New("database is in use")
+
+// lockTimeout is how long Open waits for another process to release the database
+const lockTimeout = time.Second
+
 type DB struct {
 	Database *bolt.DB
+
+	indexesMu sync.RWMutex
+	indexes   map[string]indexSet // Declared indexes, by bucket
 }
 
 type Store interface {
 	FindByID(ctx context.Context, dst interface{}, id string) error
 	FindAll(ctx context.Context, dst interface{}, filters ...Filter) error
-	//FindOne(ctx context.Context, dst interface{}, filters ...Filter) error
+	FindByIndex(ctx context.Context, dst interface{}, index string, value string) error
+	FindOne(ctx context.Context, dst interface{}, filters ...Filter) error
+	FindPage(ctx context.Context, dst interface{}, query Query) (PageInfo, error)
+	FindRange(ctx context.Context, dst interface{}, r Range) (PageInfo, error)
+	Count(ctx context.Context, filters ...Filter) (int, error)
+
+	Save(ctx context.Context, model Model) error
+	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, id string, fn func(dst interface{}) error) error
 }
 
+// Open opens the database at path, running the pending schema migrations. ErrInUse is returned when
+// another process holds it.
 func Open(path string) (*DB, error) {
-	db, err := bolt.Open(path, 0660, nil)
+	return open(path, migrations)
+}
+
+func open(path string, list []Migration) (*DB, error) {
+	bdb, err := bolt.Open(path, 0660, &bolt.Options{Timeout: lockTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, errors.Wrap(ErrInUse, path)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "bolt.Open")
 	}
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(MovieBucketName))
-		if err != nil {
-			return errors.Wrap(err, "create bucket")
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, errors.Wrap(err, "db.Update")
+	db := &DB{Database: bdb}
+	if err := db.migrate(path, list); err != nil {
+		_ = bdb.Close()
+		return nil, errors.Wrap(err, "db.migrate")
 	}
 
-	return &DB{db}, nil
+	return db, nil
 }
 
 func (db *DB) Close() error {
@@ -58,15 +82,82 @@ func (db *DB) CreateBucket(bucketName string) error {
 	return nil
 }
 
+// put writes a record and its index entries inside the given write transaction
+func (db *DB) put(tx *bolt.Tx, bucket string, key []byte, data []byte) error {
+	b := tx.Bucket([]byte(bucket))
+	if b == nil {
+		return errors.Errorf("bucket %s does not exist", bucket)
+	}
+	if err := b.Put(key, data); err != nil {
+		return errors.Wrap(err, "bucket.Put")
+	}
+	if err := db.updateIndexes(tx, bucket, key, data); err != nil {
+		return errors.Wrap(err, "db.updateIndexes")
+	}
+
+	return nil
+}
+
+// delete removes a record and its index entries inside the given write transaction
+func (db *DB) delete(tx *bolt.Tx, bucket string, key []byte) error {
+	b := tx.Bucket([]byte(bucket))
+	if b == nil {
+		return errors.Errorf("bucket %s does not exist", bucket)
+	}
+	if err := b.Delete(key); err != nil {
+		return errors.Wrap(err, "bucket.Delete")
+	}
+	if err := db.removeIndexes(tx, bucket, key); err != nil {
+		return errors.Wrap(err, "db.removeIndexes")
+	}
+
+	return nil
+}
+
 func (db *DB) Store(bucket string, key []byte, data []byte) error {
 	if db.Database == nil {
 		return errors.New("database was not initialized")
 	}
 	err := db.Database.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
-		err := b.Put(key, data)
-		if err != nil {
-			return errors.Wrap(err, "bucket.Put")
+		return db.put(tx, bucket, key, data)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+
+	return nil
+}
+
+func (db *DB) Delete(bucket string, key []byte) error {
+	if db.Database == nil {
+		return errors.New("database was not initialized")
+	}
+	err := db.Database.Update(func(tx *bolt.Tx) error {
+		return db.delete(tx, bucket, key)
+	})
+
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+
+	return nil
+}
+
+// ClearBucket removes every key of a bucket
+func (db *DB) ClearBucket(bucketName string) error {
+	if db.Database == nil {
+		return errors.New("database was not initialized")
+	}
+	err := db.Database.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket([]byte(bucketName)); err != nil && err != bolt.ErrBucketNotFound {
+			return errors.Wrap(err, "tx.DeleteBucket")
+		}
+		if _, err := tx.CreateBucket([]byte(bucketName)); err != nil {
+			return errors.Wrap(err, "tx.CreateBucket")
+		}
+		if err := db.clearIndexes(tx, bucketName); err != nil {
+			return errors.Wrap(err, "db.clearIndexes")
 		}
 
 		return nil
@@ -88,7 +179,13 @@ func (db *DB) Read(bucket []byte, key []byte) ([]byte, error) {
 
 	err := db.Database.View(func(tx *bolt.Tx) error {
 		b := tx.Bucket(bucket)
-		retVal = b.Get(key)
+		if b == nil {
+			return errors.Errorf("bucket %s does not exist", bucket)
+		}
+		// values returned by bolt are only valid during the transaction
+		if v := b.Get(key); v != nil {
+			retVal = append([]byte{}, v...)
+		}
 		return nil
 	})
 	if err != nil {
@@ -111,8 +208,9 @@ func (db *DB) BucketKeys(bucketName string) ([][]byte, error) {
 
 		c := b.Cursor()
 
+		// keys returned by bolt are only valid during the transaction
 		for k, _ := c.First(); k != nil; k, _ = c.Next() {
-			resp = append(resp, k)
+			resp = append(resp, append([]byte{}, k...))
 		}
 
 		return nil
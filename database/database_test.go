@@ -7,7 +7,11 @@ import (
 	"github.com/lsmoura/humantoken"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 type TestStruct struct {
@@ -191,3 +195,20 @@ func testStore_FindAll(t *testing.T, db *DB) {
 		t.Fatalf("store.FindAll: %s", err)
 	}
 }
+
+func TestOpenInUse(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "database.db")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	defer db.Close()
+
+	start := time.Now()
+	if _, err := Open(dbPath); !errors.Is(err, ErrInUse) {
+		t.Errorf("expected ErrInUse, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*lockTimeout {
+		t.Errorf("opening a database in use took %s", elapsed)
+	}
+}
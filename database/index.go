@@ -0,0 +1,281 @@
+package database
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// IndexFunc returns the values a record is indexed under. Each value maps to a single record, so values
+// must be unique across the bucket; when they are not, the last record written wins.
+type IndexFunc func(record Model) []string
+
+// Indexed is implemented by models declaring secondary indexes, keyed by index name.
+// Indexes are maintained on every DB.Store and DB.Delete of the model bucket.
+type Indexed interface {
+	Indexes() map[string]IndexFunc
+}
+
+type index struct {
+	name   string
+	values IndexFunc
+}
+
+// indexSet holds the indexes of a bucket, with the type records are decoded into
+type indexSet struct {
+	model   reflect.Type
+	indexes []index
+}
+
+func indexBucketName(bucket string, name string) []byte {
+	return []byte("index:" + bucket + ":" + name)
+}
+
+// indexValuesBucketName is the bucket remembering the values each record was indexed under,
+// so stale entries can be removed when the record changes
+func indexValuesBucketName(bucket string) []byte {
+	return []byte("index:" + bucket)
+}
+
+// modelType returns the struct type behind a model, which may be a pointer
+func modelType(model Model) reflect.Type {
+	t := reflect.TypeOf(model)
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+
+	return t
+}
+
+// RegisterIndexes declares the indexes of a model implementing Indexed; other models are ignored.
+// Index buckets that do not exist yet are built from the records already stored, so databases
+// created before an index was declared keep working.
+func (db *DB) RegisterIndexes(model Model) error {
+	indexed, ok := model.(Indexed)
+	if !ok {
+		return nil
+	}
+
+	bucket := model.Kind()
+	set := indexSet{model: modelType(model)}
+	for name, values := range indexed.Indexes() {
+		set.indexes = append(set.indexes, index{name: name, values: values})
+	}
+
+	db.indexesMu.Lock()
+	if db.indexes == nil {
+		db.indexes = make(map[string]indexSet)
+	}
+	db.indexes[bucket] = set
+	db.indexesMu.Unlock()
+
+	missing := false
+	err := db.Database.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(indexValuesBucketName(bucket)) == nil {
+			missing = true
+		}
+		for _, idx := range set.indexes {
+			if tx.Bucket(indexBucketName(bucket, idx.name)) == nil {
+				missing = true
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "Database.View")
+	}
+	if !missing {
+		return nil
+	}
+
+	if err := db.RebuildIndexes(bucket); err != nil {
+		return errors.Wrap(err, "db.RebuildIndexes")
+	}
+
+	return nil
+}
+
+func (db *DB) indexSet(bucket string) (indexSet, bool) {
+	db.indexesMu.RLock()
+	defer db.indexesMu.RUnlock()
+
+	set, ok := db.indexes[bucket]
+	return set, ok && len(set.indexes) > 0
+}
+
+// removeIndexes deletes every index entry of a record, inside the given write transaction
+func (db *DB) removeIndexes(tx *bolt.Tx, bucket string, key []byte) error {
+	if _, ok := db.indexSet(bucket); !ok {
+		return nil
+	}
+
+	valuesBucket, err := tx.CreateBucketIfNotExists(indexValuesBucketName(bucket))
+	if err != nil {
+		return errors.Wrap(err, "tx.CreateBucketIfNotExists")
+	}
+
+	previous := valuesBucket.Get(key)
+	if previous == nil {
+		return nil
+	}
+
+	var values map[string][]string
+	if err := json.Unmarshal(previous, &values); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+
+	for name, list := range values {
+		b := tx.Bucket(indexBucketName(bucket, name))
+		if b == nil {
+			continue
+		}
+		for _, value := range list {
+			// another record may have taken over the value since
+			if string(b.Get([]byte(value))) != string(key) {
+				continue
+			}
+			if err := b.Delete([]byte(value)); err != nil {
+				return errors.Wrap(err, "bucket.Delete")
+			}
+		}
+	}
+
+	if err := valuesBucket.Delete(key); err != nil {
+		return errors.Wrap(err, "bucket.Delete")
+	}
+
+	return nil
+}
+
+// updateIndexes replaces the index entries of a record with the ones of its new data, inside the given write transaction
+func (db *DB) updateIndexes(tx *bolt.Tx, bucket string, key []byte, data []byte) error {
+	set, ok := db.indexSet(bucket)
+	if !ok {
+		return nil
+	}
+
+	if err := db.removeIndexes(tx, bucket, key); err != nil {
+		return errors.Wrap(err, "db.removeIndexes")
+	}
+
+	record := reflect.New(set.model)
+	if err := json.Unmarshal(data, record.Interface()); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+	model, ok := record.Interface().(Model)
+	if !ok {
+		return errors.Errorf("%s does not implement Model", set.model)
+	}
+
+	values := make(map[string][]string)
+	for _, idx := range set.indexes {
+		b, err := tx.CreateBucketIfNotExists(indexBucketName(bucket, idx.name))
+		if err != nil {
+			return errors.Wrap(err, "tx.CreateBucketIfNotExists")
+		}
+
+		for _, value := range idx.values(model) {
+			if value == "" {
+				continue
+			}
+			if err := b.Put([]byte(value), key); err != nil {
+				return errors.Wrap(err, "bucket.Put")
+			}
+			values[idx.name] = append(values[idx.name], value)
+		}
+	}
+
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+	if err := tx.Bucket(indexValuesBucketName(bucket)).Put(key, encoded); err != nil {
+		return errors.Wrap(err, "bucket.Put")
+	}
+
+	return nil
+}
+
+// clearIndexes drops every index bucket of a bucket, inside the given write transaction
+func (db *DB) clearIndexes(tx *bolt.Tx, bucket string) error {
+	set, ok := db.indexSet(bucket)
+	if !ok {
+		return nil
+	}
+
+	names := [][]byte{indexValuesBucketName(bucket)}
+	for _, idx := range set.indexes {
+		names = append(names, indexBucketName(bucket, idx.name))
+	}
+	for _, name := range names {
+		if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+			return errors.Wrap(err, "tx.DeleteBucket")
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return errors.Wrap(err, "tx.CreateBucket")
+		}
+	}
+
+	return nil
+}
+
+// RebuildIndexes drops the indexes of a bucket and builds them again from its records
+func (db *DB) RebuildIndexes(bucket string) error {
+	if db.Database == nil {
+		return errors.New("database was not initialized")
+	}
+	if _, ok := db.indexSet(bucket); !ok {
+		return errors.Errorf("bucket %s has no indexes", bucket)
+	}
+
+	err := db.Database.Update(func(tx *bolt.Tx) error {
+		if err := db.clearIndexes(tx, bucket); err != nil {
+			return errors.Wrap(err, "db.clearIndexes")
+		}
+
+		b := tx.Bucket([]byte(bucket))
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			if err := db.updateIndexes(tx, bucket, k, v); err != nil {
+				return errors.Wrapf(err, "db.updateIndexes (%s)", k)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return errors.Wrap(err, "db.Update")
+	}
+
+	return nil
+}
+
+// IndexedBuckets lists the buckets with declared indexes
+func (db *DB) IndexedBuckets() []string {
+	db.indexesMu.RLock()
+	defer db.indexesMu.RUnlock()
+
+	var buckets []string
+	for bucket := range db.indexes {
+		buckets = append(buckets, bucket)
+	}
+
+	return buckets
+}
+
+// LookupIndex returns the key of the record indexed under value, or ErrNotFound
+func (db *DB) LookupIndex(bucket string, name string, value string) (Key, error) {
+	key, err := db.Read(indexBucketName(bucket, name), []byte(value))
+	if err != nil {
+		return nil, errors.Wrap(err, "db.Read")
+	}
+	if key == nil {
+		return nil, ErrNotFound
+	}
+
+	return key, nil
+}
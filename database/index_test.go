@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+type IndexedStruct struct {
+	ID   string
+	Tags []string
+}
+
+func (s *IndexedStruct) Kind() string {
+	return "indexed"
+}
+
+func (s *IndexedStruct) GetKey() Key {
+	return []byte(s.ID)
+}
+
+func (s *IndexedStruct) SetKey(key Key) {
+	s.ID = string(key)
+}
+
+func (s *IndexedStruct) Indexes() map[string]IndexFunc {
+	return map[string]IndexFunc{
+		"tag": func(record Model) []string {
+			return record.(*IndexedStruct).Tags
+		},
+	}
+}
+
+func openTestDB(t testing.TB) *DB {
+	db, err := Open(filepath.Join(t.TempDir(), "database.db"))
+	if err != nil {
+		t.Fatalf("Open: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("db.Close: %s", err)
+		}
+	})
+	if err := db.CreateBucket((&IndexedStruct{}).Kind()); err != nil {
+		t.Fatalf("db.CreateBucket: %s", err)
+	}
+
+	return db
+}
+
+func storeIndexed(t testing.TB, db *DB, v IndexedStruct) {
+	vBytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %s", err)
+	}
+	if err := db.Store(v.Kind(), v.GetKey(), vBytes); err != nil {
+		t.Fatalf("db.Store: %s", err)
+	}
+}
+
+func TestStore_FindByIndex(t *testing.T) {
+	db := openTestDB(t)
+
+	// records stored before the index is declared are indexed on registration
+	storeIndexed(t, db, IndexedStruct{ID: "old", Tags: []string{"a"}})
+	if err := db.RegisterIndexes(&IndexedStruct{}); err != nil {
+		t.Fatalf("db.RegisterIndexes: %s", err)
+	}
+	storeIndexed(t, db, IndexedStruct{ID: "new", Tags: []string{"b", "c"}})
+
+	store := NewStore(db, &IndexedStruct{})
+	lookup := func(tag string) (string, error) {
+		var v IndexedStruct
+		err := store.FindByIndex(context.Background(), &v, "tag", tag)
+		return v.ID, err
+	}
+
+	for tag, expected := range map[string]string{"a": "old", "b": "new", "c": "new"} {
+		id, err := lookup(tag)
+		if err != nil {
+			t.Errorf("%s: store.FindByIndex: %s", tag, err)
+		} else if id != expected {
+			t.Errorf("%s: expected %s, got %s", tag, expected, id)
+		}
+	}
+
+	// updating a record drops the values it no longer has
+	storeIndexed(t, db, IndexedStruct{ID: "new", Tags: []string{"c", "d"}})
+	if _, err := lookup("b"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a stale value, got %v", err)
+	}
+	if id, err := lookup("d"); err != nil || id != "new" {
+		t.Errorf("expected the new value to be indexed, got %s, %v", id, err)
+	}
+
+	if err := db.Delete((&IndexedStruct{}).Kind(), []byte("new")); err != nil {
+		t.Fatalf("db.Delete: %s", err)
+	}
+	for _, tag := range []string{"c", "d"} {
+		if _, err := lookup(tag); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound after delete, got %v", tag, err)
+		}
+	}
+
+	if err := db.RebuildIndexes((&IndexedStruct{}).Kind()); err != nil {
+		t.Fatalf("db.RebuildIndexes: %s", err)
+	}
+	if id, err := lookup("a"); err != nil || id != "old" {
+		t.Errorf("expected the rebuilt index to find old, got %s, %v", id, err)
+	}
+}
+
+// fillIndexed stores count records, each tagged with its own id
+func fillIndexed(b *testing.B, count int) *DB {
+	db := openTestDB(b)
+	db.Database.NoSync = true // one transaction per record, skip the fsync to keep the setup fast
+	if err := db.RegisterIndexes(&IndexedStruct{}); err != nil {
+		b.Fatalf("db.RegisterIndexes: %s", err)
+	}
+	for i := 0; i < count; i++ {
+		id := fmt.Sprintf("movie%05d", i)
+		storeIndexed(b, db, IndexedStruct{ID: id, Tags: []string{"nzb-" + id}})
+	}
+
+	return db
+}
+
+func BenchmarkFindByIndex10k(b *testing.B) {
+	db := fillIndexed(b, 10000)
+	store := NewStore(db, &IndexedStruct{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var v IndexedStruct
+		tag := fmt.Sprintf("nzb-movie%05d", i%10000)
+		if err := store.FindByIndex(context.Background(), &v, "tag", tag); err != nil {
+			b.Fatalf("store.FindByIndex: %s", err)
+		}
+	}
+}
+
+// BenchmarkFindByScan10k is the full scan the index replaces
+func BenchmarkFindByScan10k(b *testing.B) {
+	db := fillIndexed(b, 10000)
+	store := NewStore(db, &IndexedStruct{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tag := fmt.Sprintf("nzb-movie%05d", i%10000)
+
+		var all []IndexedStruct
+		if err := store.FindAll(context.Background(), &all); err != nil {
+			b.Fatalf("store.FindAll: %s", err)
+		}
+		found := false
+		for _, v := range all {
+			if len(v.Tags) > 0 && v.Tags[0] == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			b.Fatalf("%s not found", tag)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package database
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	MetaBucketName   = "meta"
+	schemaVersionKey = "schema_version"
+)
+
+// Migration upgrades the database to Version. Migrations run in order, inside the transaction that
+// records the new schema version, so a failing migration leaves the database untouched.
+type Migration struct {
+	Version     int
+	Description string
+	Migrate     func(tx *bolt.Tx) error
+}
+
+// migrations is the ordered list of schema changes. Append new migrations at the end, with the next
+// version number; never edit or remove one that was released.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create the movies bucket",
+		Migrate: func(tx *bolt.Tx) error {
+			if _, err := tx.CreateBucketIfNotExists([]byte(MovieBucketName)); err != nil {
+				return errors.Wrap(err, "tx.CreateBucketIfNotExists")
+			}
+			return nil
+		},
+	},
+}
+
+// SchemaVersion is the schema version this binary writes
+func SchemaVersion() int {
+	return latestVersion(migrations)
+}
+
+func latestVersion(list []Migration) int {
+	if len(list) == 0 {
+		return 0
+	}
+
+	return list[len(list)-1].Version
+}
+
+// SchemaTooNewError is returned when opening a database written by a newer version of the program
+type SchemaTooNewError struct {
+	Version   int
+	Supported int
+}
+
+func (e SchemaTooNewError) Error() string {
+	return fmt.Sprintf("database schema version %d is newer than the supported version %d", e.Version, e.Supported)
+}
+
+// schemaVersion reads the schema version recorded in the meta bucket, 0 for databases created before versioning
+func schemaVersion(tx *bolt.Tx) (int, error) {
+	b := tx.Bucket([]byte(MetaBucketName))
+	if b == nil {
+		return 0, nil
+	}
+
+	value := b.Get([]byte(schemaVersionKey))
+	if value == nil {
+		return 0, nil
+	}
+	if len(value) != 8 {
+		return 0, errors.Errorf("invalid schema version: %x", value)
+	}
+
+	return int(binary.BigEndian.Uint64(value)), nil
+}
+
+func setSchemaVersion(tx *bolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists([]byte(MetaBucketName))
+	if err != nil {
+		return errors.Wrap(err, "tx.CreateBucketIfNotExists")
+	}
+
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, uint64(version))
+	if err := b.Put([]byte(schemaVersionKey), value); err != nil {
+		return errors.Wrap(err, "bucket.Put")
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the schema version recorded in the database
+func (db *DB) SchemaVersion() (int, error) {
+	if db.Database == nil {
+		return 0, errors.New("database was not initialized")
+	}
+
+	var version int
+	err := db.Database.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = schemaVersion(tx)
+		return err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "Database.View")
+	}
+
+	return version, nil
+}
+
+// isEmpty is true for a database without any bucket, as created by bolt.Open on a new file
+func isEmpty(tx *bolt.Tx) bool {
+	empty := true
+	_ = tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		empty = false
+		return nil
+	})
+
+	return empty
+}
+
+// migrate brings the database up to the last version of list. Databases holding data are copied next
+// to path before being migrated, and databases newer than list are refused.
+func (db *DB) migrate(path string, list []Migration) error {
+	latest := latestVersion(list)
+
+	var version int
+	var empty bool
+	err := db.Database.View(func(tx *bolt.Tx) error {
+		var err error
+		version, err = schemaVersion(tx)
+		empty = isEmpty(tx)
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "Database.View")
+	}
+
+	if version > latest {
+		return SchemaTooNewError{Version: version, Supported: latest}
+	}
+	if version == latest {
+		return nil
+	}
+
+	if !empty {
+		backup := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+		err := db.Database.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(backup, 0600)
+		})
+		if err != nil {
+			return errors.Wrap(err, "backup before migration")
+		}
+	}
+
+	err = db.Database.Update(func(tx *bolt.Tx) error {
+		for _, migration := range list {
+			if migration.Version <= version {
+				continue
+			}
+			if err := migration.Migrate(tx); err != nil {
+				return errors.Wrapf(err, "migration %d (%s)", migration.Version, migration.Description)
+			}
+		}
+
+		return setSchemaVersion(tx, latest)
+	})
+	if err != nil {
+		return errors.Wrap(err, "Database.Update")
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+func createBucketMigration(version int, bucket string) Migration {
+	return Migration{
+		Version:     version,
+		Description: "create " + bucket,
+		Migrate: func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+			return err
+		},
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "database.db")
+
+	v1 := []Migration{createBucketMigration(1, "one")}
+	db, err := open(path, v1)
+	if err != nil {
+		t.Fatalf("open: %s", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != 1 {
+		t.Errorf("expected version 1, got %d (%v)", version, err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("db.Close: %s", err)
+	}
+	if backups, _ := filepath.Glob(path + ".*.bak"); len(backups) != 0 {
+		t.Errorf("new databases should not be backed up, got %v", backups)
+	}
+
+	t.Run("FailedMigration", func(t *testing.T) {
+		failing := Migration{Version: 3, Description: "fail", Migrate: func(tx *bolt.Tx) error {
+			return errors.New("broken")
+		}}
+		if _, err := open(path, append(v1, createBucketMigration(2, "two"), failing)); err == nil {
+			t.Fatalf("expected the migration to fail")
+		}
+
+		db, err := open(path, v1)
+		if err != nil {
+			t.Fatalf("open: %s", err)
+		}
+		err = db.Database.View(func(tx *bolt.Tx) error {
+			if tx.Bucket([]byte("two")) != nil {
+				return errors.New("migration 2 was not rolled back")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("db.Close: %s", err)
+		}
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		db, err := open(path, append(v1, createBucketMigration(2, "two")))
+		if err != nil {
+			t.Fatalf("open: %s", err)
+		}
+		if version, err := db.SchemaVersion(); err != nil || version != 2 {
+			t.Errorf("expected version 2, got %d (%v)", version, err)
+		}
+		if err := db.CreateBucket("two"); err != nil {
+			t.Errorf("db.CreateBucket: %s", err)
+		}
+
+		backups, err := filepath.Glob(path + ".v1-*.bak")
+		if err != nil || len(backups) == 0 {
+			t.Errorf("expected a backup of version 1, got %v (%v)", backups, err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("db.Close: %s", err)
+		}
+	})
+
+	t.Run("TooNew", func(t *testing.T) {
+		_, err := open(path, v1)
+		var tooNew SchemaTooNewError
+		if !errors.As(err, &tooNew) {
+			t.Fatalf("expected SchemaTooNewError, got %v", err)
+		}
+		if tooNew.Version != 2 || tooNew.Supported != 1 {
+			t.Errorf("unexpected error: %+v", tooNew)
+		}
+	})
+}
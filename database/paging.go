@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query selects a page of records
+type Query struct {
+	Filters []Filter
+
+	// Sort is the field, or dotted path, records are sorted by. Records are sorted by key when empty.
+	// Ties are broken by key, so the order is stable across pages.
+	Sort       string
+	Descending bool
+
+	// Limit is the maximum number of records returned, 0 meaning no limit
+	Limit  int
+	Offset int
+	// Cursor continues from the end of a previous page, as returned in PageInfo.NextCursor.
+	// Offset is applied after the cursor.
+	Cursor string
+}
+
+type PageInfo struct {
+	Total      int    `json:"total"` // Records matching the filters, on every page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the position of the last record of a page
+type cursor struct {
+	Key   string          `json:"k"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+func encodeCursor(c cursor) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "json.Marshal")
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// pageItem is a matching record with what is needed to sort it
+type pageItem struct {
+	key       string
+	value     reflect.Value
+	sortValue reflect.Value // invalid when the record has no value at the sort path
+}
+
+// sortValue returns the first value found at the sort path, or an invalid value if there is none
+func sortValue(value reflect.Value, path string) (reflect.Value, error) {
+	if path == "" {
+		return reflect.Value{}, nil
+	}
+
+	values, err := resolvePath(value, strings.Split(path, "."))
+	if err != nil {
+		return reflect.Value{}, errors.Wrap(err, "resolvePath")
+	}
+	if len(values) == 0 {
+		return reflect.Value{}, nil
+	}
+
+	return values[0], nil
+}
+
+// compareItems orders two records by sort value, then by key. Records without a sort value come first.
+func compareItems(a pageItem, b pageItem) (int, error) {
+	switch {
+	case !a.sortValue.IsValid() && b.sortValue.IsValid():
+		return -1, nil
+	case a.sortValue.IsValid() && !b.sortValue.IsValid():
+		return 1, nil
+	case a.sortValue.IsValid() && b.sortValue.IsValid():
+		result, _, err := order(a.sortValue, b.sortValue)
+		if err != nil {
+			return 0, err
+		}
+		if result != 0 {
+			return result, nil
+		}
+	}
+
+	return strings.Compare(a.key, b.key), nil
+}
+
+// cursorItem rebuilds the position stored in a cursor, decoding its sort value into the type of the
+// sort values of the page
+func cursorItem(c cursor, sample reflect.Value) (pageItem, error) {
+	item := pageItem{key: c.Key}
+	if len(c.Value) == 0 || !sample.IsValid() {
+		return item, nil
+	}
+
+	value := reflect.New(sample.Type())
+	if err := json.Unmarshal(c.Value, value.Interface()); err != nil {
+		return item, ErrInvalidCursor
+	}
+	item.sortValue = value.Elem()
+
+	return item, nil
+}
+
+// FindPage reads the records matching the query filters into dst, a pointer to a slice, sorted and
+// paginated as requested
+func (s *store) FindPage(ctx context.Context, dst interface{}, query Query) (PageInfo, error) {
+	var info PageInfo
+
+	if s.db() == nil {
+		return info, errors.New("database was not initialized")
+	}
+	if dst == nil {
+		return info, errors.New("dst cannot be nil")
+	}
+	if kind := reflect.TypeOf(dst).Kind(); kind != reflect.Ptr {
+		return info, errors.New(fmt.Sprintf("dst is not a pointer: %s", kind))
+	}
+	if ptrKind := reflect.TypeOf(dst).Elem().Kind(); ptrKind != reflect.Slice {
+		return info, errors.New(fmt.Sprintf("dst does not point to a slice: %s", ptrKind))
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return info, errors.New("limit and offset cannot be negative")
+	}
+
+	var items []pageItem
+	err := s.each(reflect.TypeOf(dst).Elem().Elem(), query.Filters, func(key []byte, value reflect.Value) error {
+		sv, err := sortValue(value, query.Sort)
+		if err != nil {
+			return errors.Wrap(err, "sortValue")
+		}
+		items = append(items, pageItem{key: string(key), value: value, sortValue: sv})
+		return nil
+	})
+	if err != nil {
+		return info, errors.Wrap(err, "s.each")
+	}
+	info.Total = len(items)
+
+	var sortErr error
+	sort.SliceStable(items, func(i, j int) bool {
+		result, err := compareItems(items[i], items[j])
+		if err != nil {
+			sortErr = err
+		}
+		if query.Descending {
+			return result > 0
+		}
+		return result < 0
+	})
+	if sortErr != nil {
+		return info, errors.Wrapf(sortErr, "sort by %s", query.Sort)
+	}
+
+	start := 0
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor)
+		if err != nil {
+			return info, err
+		}
+
+		var sample reflect.Value
+		for _, item := range items {
+			if item.sortValue.IsValid() {
+				sample = item.sortValue
+				break
+			}
+		}
+		last, err := cursorItem(c, sample)
+		if err != nil {
+			return info, err
+		}
+
+		// the first record after the cursor position, which still works if that record was deleted
+		start = sort.Search(len(items), func(i int) bool {
+			result, err := compareItems(items[i], last)
+			if err != nil {
+				sortErr = err
+			}
+			if query.Descending {
+				return result < 0
+			}
+			return result > 0
+		})
+		if sortErr != nil {
+			return info, ErrInvalidCursor
+		}
+	}
+
+	start += query.Offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := len(items)
+	if query.Limit > 0 && start+query.Limit < end {
+		end = start + query.Limit
+	}
+
+	slice := reflect.MakeSlice(reflect.TypeOf(dst).Elem(), 0, end-start)
+	for _, item := range items[start:end] {
+		slice = reflect.Append(slice, item.value)
+	}
+	reflect.ValueOf(dst).Elem().Set(slice)
+
+	if end < len(items) && end > start {
+		last := items[end-1]
+		c := cursor{Key: last.key}
+		if last.sortValue.IsValid() {
+			c.Value, err = json.Marshal(last.sortValue.Interface())
+			if err != nil {
+				return info, errors.Wrap(err, "json.Marshal")
+			}
+		}
+		info.NextCursor, err = encodeCursor(c)
+		if err != nil {
+			return info, errors.Wrap(err, "encodeCursor")
+		}
+	}
+
+	return info, nil
+}
+
+// Count returns the number of records matching the filters
+func (s *store) Count(ctx context.Context, filters ...Filter) (int, error) {
+	if s.db() == nil {
+		return 0, errors.New("database was not initialized")
+	}
+
+	count := 0
+	err := s.each(modelType(s.model), filters, func(key []byte, value reflect.Value) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "s.each")
+	}
+
+	return count, nil
+}
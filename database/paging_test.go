@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// pageIDs reads every page of a query, returning the ids in order
+func pageIDs(t *testing.T, store *store, query Query) ([]string, int) {
+	var ids []string
+	total := 0
+	for page := 0; page < 100; page++ {
+		var records []IndexedStruct
+		info, err := store.FindPage(context.Background(), &records, query)
+		if err != nil {
+			t.Fatalf("store.FindPage: %s", err)
+		}
+		total = info.Total
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		if info.NextCursor == "" {
+			return ids, total
+		}
+		query.Cursor = info.NextCursor
+	}
+
+	t.Fatal("pagination does not end")
+	return nil, 0
+}
+
+func TestStore_FindPage(t *testing.T) {
+	db := openTestDB(t)
+	store := NewStore(db, &IndexedStruct{})
+	ctx := context.Background()
+
+	// tags sort in the opposite order of ids, with ties on "b"
+	records := map[string][]string{"r1": {"c"}, "r2": {"b"}, "r3": {"b"}, "r4": {"a"}, "r5": nil}
+	for id, tags := range records {
+		if err := store.Save(ctx, &IndexedStruct{ID: id, Tags: tags}); err != nil {
+			t.Fatalf("store.Save: %s", err)
+		}
+	}
+
+	testCases := []struct {
+		query    Query
+		expected string
+	}{
+		{query: Query{}, expected: "[r1 r2 r3 r4 r5]"},
+		{query: Query{Limit: 2}, expected: "[r1 r2 r3 r4 r5]"},
+		{query: Query{Sort: "Tags", Limit: 2}, expected: "[r5 r4 r2 r3 r1]"},
+		{query: Query{Sort: "Tags", Descending: true, Limit: 3}, expected: "[r1 r3 r2 r4 r5]"},
+		{query: Query{Sort: "ID", Descending: true, Limit: 1}, expected: "[r5 r4 r3 r2 r1]"},
+		{query: Query{Offset: 3}, expected: "[r4 r5]"},
+		{query: Query{Offset: 10}, expected: "[]"},
+		{query: Query{Filters: []Filter{{"Tags": {Operator: Equal, Value: "b"}}}, Limit: 1}, expected: "[r2 r3]"},
+	}
+	for _, tc := range testCases {
+		ids, total := pageIDs(t, store, tc.query)
+		if got := fmt.Sprint(ids); got != tc.expected {
+			t.Errorf("%+v: expected %s, got %s", tc.query, tc.expected, got)
+		}
+		if tc.query.Filters == nil && total != len(records) {
+			t.Errorf("%+v: expected total %d, got %d", tc.query, len(records), total)
+		}
+	}
+
+	// a page keeps going after its last record is deleted
+	var first []IndexedStruct
+	info, err := store.FindPage(ctx, &first, Query{Sort: "Tags", Limit: 2})
+	if err != nil {
+		t.Fatalf("store.FindPage: %s", err)
+	}
+	if err := store.Delete(ctx, first[1].ID); err != nil {
+		t.Fatalf("store.Delete: %s", err)
+	}
+	ids, _ := pageIDs(t, store, Query{Sort: "Tags", Limit: 2, Cursor: info.NextCursor})
+	if got := fmt.Sprint(ids); got != "[r2 r3 r1]" {
+		t.Errorf("expected [r2 r3 r1] after the deleted cursor, got %s", got)
+	}
+
+	var records2 []IndexedStruct
+	if _, err := store.FindPage(ctx, &records2, Query{Cursor: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+
+	count, err := store.Count(ctx, Filter{"Tags": {Operator: In, Value: []string{"a", "b"}}})
+	if err != nil {
+		t.Fatalf("store.Count: %s", err)
+	}
+	// r4 was deleted above
+	if count != 2 {
+		t.Errorf("expected 2 records, got %d", count)
+	}
+}
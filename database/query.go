@@ -2,20 +2,43 @@ package database
 
 import (
 	"fmt"
-	"github.com/pkg/errors"
 	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 type operator int
 
+// Ordering operators read as "Value <operator> field": {LessThan, 5} matches fields greater than 5.
 const (
 	LessThan      operator = iota // 0
 	LessThanEq                    // 1
 	Equal                         // 2
 	GreaterThan                   // 3
 	GreaterThanEq                 // 4
+	NotEqual                      // 5
+	In                            // 6, Value is a slice of accepted values
+	NotIn                         // 7, Value is a slice of rejected values
+	Contains                      // 8, the field string contains Value
+	HasPrefix                     // 9, the field string starts with Value
+	EqualFold                     // 10, case-insensitive string equality
+	Matches                       // 11, Value is a regular expression, either a string or a *regexp.Regexp
+
+	anyOf // Value is a list of filters, at least one of them must match. See Or.
 )
 
+// OrKey is the Filter key holding the alternatives created by Or
+const OrKey = "$or"
+
+// Filter maps field names to the comparison their values must pass. Every comparison of a filter, and
+// every filter given to a query, must match.
+//
+// Field names may be dotted paths into nested structs, maps and slices, like "NzbInfo.Status". When a path
+// goes through a slice, the comparison matches if any element does; negated operators (NotEqual, NotIn)
+// match only if no element matches the positive one.
 type Filter map[string]Comparison
 
 type Comparison struct {
@@ -23,33 +46,317 @@ type Comparison struct {
 	Value    interface{}
 }
 
-func (c Comparison) compare(value interface{}) (bool, error) {
-	typeOfValue := reflect.TypeOf(value)
-	if typeOfValue.Kind() != reflect.TypeOf(c.Value).Kind() {
-		return false, errors.New(fmt.Sprintf("cannot compare different types: %s, %s", typeOfValue.Kind(), reflect.TypeOf(c.Value).Kind()))
+// Or returns a filter matching records that match at least one of the given filters
+func Or(filters ...Filter) Filter {
+	return Filter{OrKey: {Operator: anyOf, Value: filters}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+func isNumber(k reflect.Kind) bool {
+	return isInt(k) || isUint(k) || isFloat(k)
+}
+
+// compareNumbers returns -1, 0 or 1 when a is less than, equal to or greater than b
+func compareNumbers(a reflect.Value, b reflect.Value) int {
+	ak, bk := a.Kind(), b.Kind()
+
+	switch {
+	case isFloat(ak) || isFloat(bk):
+		var af, bf float64
+		switch {
+		case isFloat(ak):
+			af = a.Float()
+		case isInt(ak):
+			af = float64(a.Int())
+		default:
+			af = float64(a.Uint())
+		}
+		switch {
+		case isFloat(bk):
+			bf = b.Float()
+		case isInt(bk):
+			bf = float64(b.Int())
+		default:
+			bf = float64(b.Uint())
+		}
+		return compareOrdered(af < bf, af > bf)
+	case isInt(ak) && isInt(bk):
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case isUint(ak) && isUint(bk):
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case isInt(ak):
+		if a.Int() < 0 {
+			return -1
+		}
+		return compareOrdered(uint64(a.Int()) < b.Uint(), uint64(a.Int()) > b.Uint())
+	default:
+		if b.Int() < 0 {
+			return 1
+		}
+		return compareOrdered(a.Uint() < uint64(b.Int()), a.Uint() > uint64(b.Int()))
+	}
+}
+
+func compareOrdered(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// order compares two values of compatible types, returning -1, 0 or 1. ordered is false for types
+// that can only be compared for equality, like bools.
+func order(a reflect.Value, b reflect.Value) (result int, ordered bool, err error) {
+	if !a.IsValid() || !b.IsValid() {
+		return 0, false, errors.New("cannot compare nil values")
+	}
+	ak, bk := a.Kind(), b.Kind()
+
+	switch {
+	case a.Type() == timeType && b.Type() == timeType:
+		at, bt := a.Interface().(time.Time), b.Interface().(time.Time)
+		return compareOrdered(at.Before(bt), at.After(bt)), true, nil
+	case isNumber(ak) && isNumber(bk):
+		return compareNumbers(a, b), true, nil
+	case ak == reflect.String && bk == reflect.String:
+		return strings.Compare(a.String(), b.String()), true, nil
+	case ak == reflect.Bool && bk == reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0, false, nil
+		}
+		return 1, false, nil
+	}
+
+	return 0, false, errors.New(fmt.Sprintf("cannot compare different types: %s, %s", a.Type(), b.Type()))
+}
+
+func (c Comparison) regexp() (*regexp.Regexp, error) {
+	switch v := c.Value.(type) {
+	case *regexp.Regexp:
+		return v, nil
+	case string:
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "regexp.Compile")
+		}
+		return re, nil
 	}
 
-	switch typeOfValue.Kind() {
-	case reflect.String:
-		if c.Operator == Equal {
-			return value.(string) == c.Value.(string), nil
+	return nil, errors.Errorf("invalid regular expression: %T", c.Value)
+}
+
+// compareValue checks a single field value against the comparison, with negated operators applied as
+// their positive counterpart
+func (c Comparison) compareValue(value reflect.Value) (bool, error) {
+	filterValue := reflect.ValueOf(c.Value)
+
+	switch c.Operator {
+	case In, NotIn:
+		if filterValue.Kind() != reflect.Slice && filterValue.Kind() != reflect.Array {
+			return false, errors.Errorf("%d operator needs a slice, got %T", c.Operator, c.Value)
+		}
+		for i := 0; i < filterValue.Len(); i++ {
+			result, _, err := order(reflect.Indirect(reflect.ValueOf(filterValue.Index(i).Interface())), value)
+			if err != nil {
+				return false, errors.Wrap(err, "order")
+			}
+			if result == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case Contains, HasPrefix, EqualFold:
+		if value.Kind() != reflect.String || filterValue.Kind() != reflect.String {
+			return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", value.Kind(), c.Operator))
 		}
-		return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", typeOfValue.Kind(), c.Operator))
-	case reflect.Int32:
 		switch c.Operator {
-		case Equal:
-			return c.Value.(int32) == value.(int32), nil
-		case LessThan:
-			return c.Value.(int32) < value.(int32), nil
-		case LessThanEq:
-			return c.Value.(int32) <= value.(int32), nil
-		case GreaterThan:
-			return c.Value.(int32) > value.(int32), nil
-		case GreaterThanEq:
-			return c.Value.(int32) >= value.(int32), nil
+		case Contains:
+			return strings.Contains(value.String(), filterValue.String()), nil
+		case HasPrefix:
+			return strings.HasPrefix(value.String(), filterValue.String()), nil
+		default:
+			return strings.EqualFold(value.String(), filterValue.String()), nil
+		}
+	case Matches:
+		if value.Kind() != reflect.String {
+			return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", value.Kind(), c.Operator))
+		}
+		re, err := c.regexp()
+		if err != nil {
+			return false, errors.Wrap(err, "c.regexp")
+		}
+		return re.MatchString(value.String()), nil
+	}
+
+	if !filterValue.IsValid() {
+		return false, errors.New("comparison value cannot be nil")
+	}
+
+	result, ordered, err := order(filterValue, value)
+	if err != nil {
+		return false, err
+	}
+
+	switch c.Operator {
+	case Equal, NotEqual:
+		return result == 0, nil
+	}
+	if !ordered {
+		return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", value.Kind(), c.Operator))
+	}
+
+	switch c.Operator {
+	case LessThan:
+		return result < 0, nil
+	case LessThanEq:
+		return result <= 0, nil
+	case GreaterThan:
+		return result > 0, nil
+	case GreaterThanEq:
+		return result >= 0, nil
+	}
+
+	return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", value.Kind(), c.Operator))
+}
+
+func (c Comparison) negated() bool {
+	return c.Operator == NotEqual || c.Operator == NotIn
+}
+
+// compareAll checks the values found at a field path: the comparison matches if any value does, or, for
+// negated operators, if none matches the positive operator
+func (c Comparison) compareAll(values []reflect.Value) (bool, error) {
+	found := false
+	for _, value := range values {
+		result, err := c.compareValue(value)
+		if err != nil {
+			return false, err
+		}
+		if result {
+			found = true
+			break
+		}
+	}
+
+	if c.negated() {
+		return !found, nil
+	}
+
+	return found, nil
+}
+
+func (c Comparison) compare(value interface{}) (bool, error) {
+	return c.compareAll([]reflect.Value{reflect.Indirect(reflect.ValueOf(value))})
+}
+
+// resolvePath returns the values found at a dotted field path. Pointers are followed and slices are
+// flattened, so a path through a slice returns a value per element.
+func resolvePath(value reflect.Value, path []string) ([]reflect.Value, error) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Type().Elem().Kind() != reflect.Uint8 {
+		var values []reflect.Value
+		for i := 0; i < value.Len(); i++ {
+			found, err := resolvePath(value.Index(i), path)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, found...)
+		}
+		return values, nil
+	}
+
+	if len(path) == 0 {
+		return []reflect.Value{value}, nil
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		field := value.FieldByName(path[0])
+		if !field.IsValid() {
+			return nil, errors.Errorf("%s has no field %s", value.Type(), path[0])
+		}
+		return resolvePath(field, path[1:])
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, errors.Errorf("cannot look up %s in %s", path[0], value.Type())
+		}
+		item := value.MapIndex(reflect.ValueOf(path[0]).Convert(value.Type().Key()))
+		if !item.IsValid() {
+			return nil, nil
+		}
+		return resolvePath(item, path[1:])
+	}
+
+	return nil, errors.Errorf("cannot look up %s in %s", path[0], value.Type())
+}
+
+// matchFilter checks every comparison of a single filter
+func matchFilter(value reflect.Value, filter Filter) (bool, error) {
+	for key, comparison := range filter {
+		if comparison.Operator == anyOf {
+			alternatives, ok := comparison.Value.([]Filter)
+			if !ok {
+				return false, errors.Errorf("invalid %s value: %T", OrKey, comparison.Value)
+			}
+			result, err := matchAny(value, alternatives)
+			if err != nil {
+				return false, err
+			}
+			if !result {
+				return false, nil
+			}
+			continue
+		}
+
+		values, err := resolvePath(value, strings.Split(key, "."))
+		if err != nil {
+			return false, errors.Wrap(err, "resolvePath")
+		}
+		result, err := comparison.compareAll(values)
+		if err != nil {
+			return false, errors.Wrapf(err, "compare %s", key)
+		}
+		if !result {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func matchAny(value reflect.Value, filters []Filter) (bool, error) {
+	for _, filter := range filters {
+		result, err := matchFilter(value, filter)
+		if err != nil {
+			return false, err
+		}
+		if result {
+			return true, nil
 		}
-		return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", typeOfValue.Kind(), c.Operator))
 	}
 
-	return false, errors.New(fmt.Sprintf("comparison not implemented: %s, %d", typeOfValue.Kind(), c.Operator))
+	return false, nil
 }
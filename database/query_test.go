@@ -0,0 +1,114 @@
+package database
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+type queryRelease struct {
+	Status string
+	Size   int64
+	Tags   []string
+}
+
+type queryRecord struct {
+	Title    string
+	Year     int
+	Rating   float32
+	Monitor  bool
+	Added    time.Time
+	Releases []queryRelease
+	Extra    map[string]string
+	Parent   *queryRecord
+}
+
+func TestFilterOperators(t *testing.T) {
+	added := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	record := queryRecord{
+		Title:   "The Matrix",
+		Year:    1999,
+		Rating:  8.7,
+		Monitor: true,
+		Added:   added,
+		Releases: []queryRelease{
+			{Status: "failed", Size: 100, Tags: []string{"x264"}},
+			{Status: "snatched", Size: 200, Tags: []string{"x265", "hdr"}},
+		},
+		Extra:  map[string]string{"edition": "Remastered"},
+		Parent: &queryRecord{Title: "Collection"},
+	}
+
+	testCases := []struct {
+		name        string
+		filters     []Filter
+		expectError bool
+		result      bool
+	}{
+		{name: "not equal", filters: []Filter{{"Title": {Operator: NotEqual, Value: "Heat"}}}, result: true},
+		{name: "in", filters: []Filter{{"Year": {Operator: In, Value: []int{1998, 1999}}}}, result: true},
+		{name: "in mixed widths", filters: []Filter{{"Year": {Operator: In, Value: []interface{}{int64(1999)}}}}, result: true},
+		{name: "not in", filters: []Filter{{"Year": {Operator: NotIn, Value: []int{1998, 1999}}}}, result: false},
+		{name: "contains", filters: []Filter{{"Title": {Operator: Contains, Value: "Matr"}}}, result: true},
+		{name: "has prefix", filters: []Filter{{"Title": {Operator: HasPrefix, Value: "Matrix"}}}, result: false},
+		{name: "equal fold", filters: []Filter{{"Title": {Operator: EqualFold, Value: "the MATRIX"}}}, result: true},
+		{name: "regex string", filters: []Filter{{"Title": {Operator: Matches, Value: `^The \w+$`}}}, result: true},
+		{name: "regex compiled", filters: []Filter{{"Title": {Operator: Matches, Value: regexp.MustCompile(`\d`)}}}, result: false},
+		{name: "invalid regex", filters: []Filter{{"Title": {Operator: Matches, Value: `(`}}}, expectError: true},
+		{name: "int against int64", filters: []Filter{{"Year": {Operator: Equal, Value: int64(1999)}}}, result: true},
+		{name: "int against uint", filters: []Filter{{"Year": {Operator: LessThan, Value: uint8(200)}}}, result: true},
+		{name: "float", filters: []Filter{{"Rating": {Operator: GreaterThan, Value: 9.0}}}, result: true},
+		{name: "bool", filters: []Filter{{"Monitor": {Operator: Equal, Value: true}}}, result: true},
+		{name: "bool order", filters: []Filter{{"Monitor": {Operator: LessThan, Value: true}}}, expectError: true},
+		{name: "time", filters: []Filter{{"Added": {Operator: LessThan, Value: added.Add(-time.Hour)}}}, result: true},
+		{name: "time equal", filters: []Filter{{"Added": {Operator: Equal, Value: added.In(time.Local)}}}, result: true},
+		{name: "slice any match", filters: []Filter{{"Releases.Status": {Operator: Equal, Value: "snatched"}}}, result: true},
+		{name: "slice no match", filters: []Filter{{"Releases.Status": {Operator: Equal, Value: "success"}}}, result: false},
+		{name: "slice not equal", filters: []Filter{{"Releases.Status": {Operator: NotEqual, Value: "failed"}}}, result: false},
+		{name: "nested slice", filters: []Filter{{"Releases.Tags": {Operator: Equal, Value: "hdr"}}}, result: true},
+		{name: "slice numbers", filters: []Filter{{"Releases.Size": {Operator: LessThan, Value: 150}}}, result: true},
+		{name: "map", filters: []Filter{{"Extra.edition": {Operator: Equal, Value: "Remastered"}}}, result: true},
+		{name: "missing map key", filters: []Filter{{"Extra.cut": {Operator: Equal, Value: "Final"}}}, result: false},
+		{name: "pointer", filters: []Filter{{"Parent.Title": {Operator: Equal, Value: "Collection"}}}, result: true},
+		{name: "unknown field", filters: []Filter{{"Nothing": {Operator: Equal, Value: "x"}}}, expectError: true},
+		{name: "type mismatch", filters: []Filter{{"Title": {Operator: Equal, Value: 3}}}, expectError: true},
+		{
+			name: "or",
+			filters: []Filter{Or(
+				Filter{"Title": {Operator: Equal, Value: "Heat"}},
+				Filter{"Year": {Operator: Equal, Value: 1999}},
+			)},
+			result: true,
+		},
+		{
+			name: "or and",
+			filters: []Filter{
+				Or(Filter{"Title": {Operator: Equal, Value: "Heat"}}, Filter{"Year": {Operator: Equal, Value: 1999}}),
+				{"Monitor": {Operator: Equal, Value: false}},
+			},
+			result: false,
+		},
+		{
+			name:    "or none",
+			filters: []Filter{Or(Filter{"Title": {Operator: Equal, Value: "Heat"}}, Filter{"Year": {Operator: Equal, Value: 1995}})},
+			result:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		result, err := checkFilters(record, tc.filters)
+		if tc.expectError {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tc.name, err)
+			continue
+		}
+		if result != tc.result {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.result, result)
+		}
+	}
+}
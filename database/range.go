@@ -0,0 +1,170 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Range selects records in the order of their keys, or of the values of an index, read with a bucket
+// cursor. Unlike FindPage, only the records of the page are decoded.
+type Range struct {
+	// Index is the index whose values records are read in the order of, the keys being used when empty
+	Index string
+	// Prefix limits the records to the keys, or index values, starting with it
+	Prefix     string
+	Descending bool
+
+	// Limit is the maximum number of records returned, 0 meaning no limit
+	Limit int
+	// Cursor continues from the end of a previous page, as returned in PageInfo.NextCursor
+	Cursor string
+}
+
+// prefixEnd returns the first key after every key starting with prefix, or nil if there is none
+func prefixEnd(prefix []byte) []byte {
+	end := append([]byte{}, prefix...)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// seekRange positions a cursor on the first key of the range, after the from position when not nil
+func seekRange(c *bolt.Cursor, prefix []byte, from []byte, descending bool) []byte {
+	if !descending {
+		if from == nil {
+			k, _ := c.Seek(prefix)
+			return k
+		}
+		k, _ := c.Seek(from)
+		if k != nil && bytes.Equal(k, from) {
+			k, _ = c.Next()
+		}
+		return k
+	}
+
+	// the last key before the bound, which still works if the record at the cursor was deleted
+	bound := from
+	if bound == nil {
+		bound = prefixEnd(prefix)
+	}
+	if bound == nil {
+		k, _ := c.Last()
+		return k
+	}
+	if k, _ := c.Seek(bound); k == nil {
+		k, _ = c.Last()
+		return k
+	}
+	k, _ := c.Prev()
+	return k
+}
+
+// FindRange reads a page of the records in the range into dst, a pointer to a slice. The total is the
+// number of keys, or index values, in the range.
+func (s *store) FindRange(ctx context.Context, dst interface{}, r Range) (PageInfo, error) {
+	var info PageInfo
+
+	if s.db() == nil {
+		return info, errors.New("database was not initialized")
+	}
+	if dst == nil {
+		return info, errors.New("dst cannot be nil")
+	}
+	if kind := reflect.TypeOf(dst).Kind(); kind != reflect.Ptr {
+		return info, errors.New(fmt.Sprintf("dst is not a pointer: %s", kind))
+	}
+	if ptrKind := reflect.TypeOf(dst).Elem().Kind(); ptrKind != reflect.Slice {
+		return info, errors.New(fmt.Sprintf("dst does not point to a slice: %s", ptrKind))
+	}
+	if r.Limit < 0 {
+		return info, errors.New("limit cannot be negative")
+	}
+
+	var from []byte
+	if r.Cursor != "" {
+		c, err := decodeCursor(r.Cursor)
+		if err != nil {
+			return info, err
+		}
+		from = []byte(c.Key)
+	}
+
+	bucketName := s.model.Kind()
+	prefix := []byte(r.Prefix)
+	t := reflect.TypeOf(dst).Elem().Elem()
+	slice := reflect.MakeSlice(reflect.TypeOf(dst).Elem(), 0, r.Limit)
+
+	err := s.db().View(func(tx *bolt.Tx) error {
+		records := tx.Bucket([]byte(bucketName))
+		if records == nil {
+			return errors.Errorf("bucket %s does not exist", bucketName)
+		}
+		positions := records
+		if r.Index != "" {
+			positions = tx.Bucket(indexBucketName(bucketName, r.Index))
+			if positions == nil {
+				return errors.Errorf("index %s of %s does not exist", r.Index, bucketName)
+			}
+		}
+
+		c := positions.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			info.Total++
+		}
+
+		next := c.Next
+		if r.Descending {
+			next = c.Prev
+		}
+		var last []byte
+		for k := seekRange(c, prefix, from, r.Descending); k != nil && bytes.HasPrefix(k, prefix); k, _ = next() {
+			if r.Limit > 0 && slice.Len() == r.Limit {
+				// a record is left after the page
+				info.NextCursor = string(last)
+				break
+			}
+
+			key := k
+			if r.Index != "" {
+				key = positions.Get(k)
+			}
+			data := records.Get(key)
+			if data == nil {
+				continue
+			}
+			m := reflect.New(t)
+			if err := json.Unmarshal(data, m.Interface()); err != nil {
+				return errors.Wrap(err, "json.Unmarshal")
+			}
+			slice = reflect.Append(slice, m.Elem())
+			// keys returned by bolt are only valid during the transaction
+			last = append([]byte{}, k...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return info, errors.Wrap(err, "Database.View")
+	}
+	reflect.ValueOf(dst).Elem().Set(slice)
+
+	if info.NextCursor != "" {
+		info.NextCursor, err = encodeCursor(cursor{Key: info.NextCursor})
+		if err != nil {
+			return info, errors.Wrap(err, "encodeCursor")
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// rangeIDs reads every page of a range, returning the ids in order
+func rangeIDs(t *testing.T, store *store, r Range) ([]string, int) {
+	var ids []string
+	total := 0
+	for page := 0; page < 100; page++ {
+		var records []IndexedStruct
+		info, err := store.FindRange(context.Background(), &records, r)
+		if err != nil {
+			t.Fatalf("store.FindRange: %s", err)
+		}
+		total = info.Total
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		if info.NextCursor == "" {
+			return ids, total
+		}
+		r.Cursor = info.NextCursor
+	}
+
+	t.Fatal("pagination does not end")
+	return nil, 0
+}
+
+func TestStore_FindRange(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.RegisterIndexes(&IndexedStruct{}); err != nil {
+		t.Fatalf("db.RegisterIndexes: %s", err)
+	}
+	for _, v := range []IndexedStruct{
+		{ID: "a1", Tags: []string{"x/1"}},
+		{ID: "a2", Tags: []string{"y/2"}},
+		{ID: "b1", Tags: []string{"x/3"}},
+		{ID: "b2", Tags: []string{"x/4"}},
+		{ID: "c1", Tags: []string{"y/5"}},
+	} {
+		storeIndexed(t, db, v)
+	}
+	store := NewStore(db, &IndexedStruct{})
+
+	testCases := []struct {
+		name     string
+		r        Range
+		expected []string
+		total    int
+	}{
+		{name: "keys", r: Range{Limit: 2}, expected: []string{"a1", "a2", "b1", "b2", "c1"}, total: 5},
+		{name: "keys descending", r: Range{Descending: true, Limit: 2}, expected: []string{"c1", "b2", "b1", "a2", "a1"}, total: 5},
+		{name: "key prefix", r: Range{Prefix: "b", Descending: true, Limit: 1}, expected: []string{"b2", "b1"}, total: 2},
+		{name: "index prefix", r: Range{Index: "tag", Prefix: "x/", Limit: 2}, expected: []string{"a1", "b1", "b2"}, total: 3},
+		{name: "index prefix descending", r: Range{Index: "tag", Prefix: "x/", Descending: true, Limit: 2}, expected: []string{"b2", "b1", "a1"}, total: 3},
+		{name: "no match", r: Range{Prefix: "z"}, expected: nil, total: 0},
+	}
+
+	for _, tc := range testCases {
+		ids, total := rangeIDs(t, store, tc.r)
+		if !reflect.DeepEqual(ids, tc.expected) || total != tc.total {
+			t.Errorf("%s: expected %v (%d), got %v (%d)", tc.name, tc.expected, tc.total, ids, total)
+		}
+	}
+
+	// a page continues after its cursor even if the record there was deleted
+	var records []IndexedStruct
+	info, err := store.FindRange(context.Background(), &records, Range{Descending: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("store.FindRange: %s", err)
+	}
+	if err := store.Delete(context.Background(), "b2"); err != nil {
+		t.Fatalf("store.Delete: %s", err)
+	}
+	if ids, _ := rangeIDs(t, store, Range{Descending: true, Limit: 2, Cursor: info.NextCursor}); !reflect.DeepEqual(ids, []string{"b1", "a2", "a1"}) {
+		t.Errorf("unexpected records after a deleted cursor: %v", ids)
+	}
+
+	if _, err := store.FindRange(context.Background(), &records, Range{Cursor: "nope"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
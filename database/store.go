@@ -12,6 +12,9 @@ import (
 
 type Key []byte
 
+// ErrNotFound is returned when the requested record does not exist
+var ErrNotFound = errors.New("not found")
+
 type Model interface {
 	Kind() string
 
@@ -48,7 +51,7 @@ func (s *store) FindByID(ctx context.Context, dst interface{}, id string) error
 	}
 
 	if v == nil {
-		return errors.New("not found")
+		return ErrNotFound
 	}
 
 	if err := json.Unmarshal(v, &dst); err != nil {
@@ -58,58 +61,60 @@ func (s *store) FindByID(ctx context.Context, dst interface{}, id string) error
 	return nil
 }
 
+// FindByIndex reads the record indexed under value by one of the model's declared indexes
+func (s *store) FindByIndex(ctx context.Context, dst interface{}, index string, value string) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+
+	key, err := s.session.LookupIndex(s.model.Kind(), index, value)
+	if err != nil {
+		return errors.Wrap(err, "db.LookupIndex")
+	}
+
+	if err := s.FindByID(ctx, dst, string(key)); err != nil {
+		return errors.Wrap(err, "s.FindByID")
+	}
+
+	return nil
+}
+
 func checkFilters(dst interface{}, filters []Filter) (bool, error) {
 	if dst == nil {
 		return false, errors.New("cannot compare without an object")
 	}
-	if filters == nil {
-		return true, nil
-	}
 
-	valueOf := reflect.ValueOf(dst)
-	for _, filterMap := range filters {
-		for key, comparison := range filterMap {
-			value := valueOf.FieldByName(key)
-			result, err := comparison.compare(value.Interface())
-			if err != nil {
-				return false, errors.Wrap(err, "compare")
-			}
-			if !result {
-				return false, nil
-			}
+	value := reflect.ValueOf(dst)
+	for _, filter := range filters {
+		result, err := matchFilter(value, filter)
+		if err != nil {
+			return false, errors.Wrap(err, "matchFilter")
+		}
+		if !result {
+			return false, nil
 		}
 	}
 
 	return true, nil
 }
 
-func (s *store) FindAll(ctx context.Context, dst interface{}, filters ...Filter) error {
-	if s.db() == nil {
-		return errors.New("database was not initialized")
-	}
-
-	if dst == nil {
-		return errors.New("dst cannot be nil")
-	}
-	if kind := reflect.TypeOf(dst).Kind(); kind != reflect.Ptr {
-		return errors.New(fmt.Sprintf("dst is not a pointer: %s", kind))
-	}
-	if ptrKind := reflect.TypeOf(dst).Elem().Kind(); ptrKind != reflect.Slice {
-		return errors.New(fmt.Sprintf("dst does not point to a slice: %s", ptrKind))
-	}
-	myType := reflect.TypeOf(s.model)
+// errStopIteration ends the iteration of each early
+var errStopIteration = errors.New("stop iteration")
 
+// each decodes every record matching the filters into a new value of type t, stopping early when fn returns errStopIteration
+func (s *store) each(t reflect.Type, filters []Filter, fn func(key []byte, value reflect.Value) error) error {
 	bucketName := s.model.Kind()
-	slice := reflect.ValueOf(dst).Elem()
 
 	err := s.db().View(func(tx *bolt.Tx) error {
-		// Assume bucket exists and has keys
 		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.Errorf("bucket %s does not exist", bucketName)
+		}
 
 		c := b.Cursor()
 
 		for k, bytes := c.First(); k != nil; k, bytes = c.Next() {
-			m := reflect.New(myType)
+			m := reflect.New(t)
 			err := json.Unmarshal(bytes, m.Interface())
 			if err != nil {
 				return errors.Wrap(err, "json.Unmarshal")
@@ -121,17 +126,196 @@ func (s *store) FindAll(ctx context.Context, dst interface{}, filters ...Filter)
 			}
 
 			if shouldInclude {
-				slice = reflect.Append(slice, m.Elem())
+				if err := fn(k, m.Elem()); err != nil {
+					return err
+				}
 			}
 		}
 
 		return nil
 	})
+	if err == errStopIteration {
+		return nil
+	}
 	if err != nil {
 		return errors.Wrap(err, "Database.View")
 	}
 
+	return nil
+}
+
+func (s *store) FindAll(ctx context.Context, dst interface{}, filters ...Filter) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+
+	if dst == nil {
+		return errors.New("dst cannot be nil")
+	}
+	if kind := reflect.TypeOf(dst).Kind(); kind != reflect.Ptr {
+		return errors.New(fmt.Sprintf("dst is not a pointer: %s", kind))
+	}
+	if ptrKind := reflect.TypeOf(dst).Elem().Kind(); ptrKind != reflect.Slice {
+		return errors.New(fmt.Sprintf("dst does not point to a slice: %s", ptrKind))
+	}
+	// records are decoded into the slice element type, so both values and pointers are supported
+	myType := reflect.TypeOf(dst).Elem().Elem()
+
+	slice := reflect.ValueOf(dst).Elem()
+
+	err := s.each(myType, filters, func(key []byte, value reflect.Value) error {
+		slice = reflect.Append(slice, value)
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "s.each")
+	}
+
 	reflect.ValueOf(dst).Elem().Set(slice)
 
 	return nil
 }
+
+// FindOne reads the first record, in key order, matching the filters. ErrNotFound is returned if none does.
+func (s *store) FindOne(ctx context.Context, dst interface{}, filters ...Filter) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+
+	if dst == nil {
+		return errors.New("dst cannot be nil")
+	}
+	if kind := reflect.TypeOf(dst).Kind(); kind != reflect.Ptr {
+		return errors.New(fmt.Sprintf("dst is not a pointer: %s", kind))
+	}
+
+	found := false
+	err := s.each(reflect.TypeOf(dst).Elem(), filters, func(key []byte, value reflect.Value) error {
+		reflect.ValueOf(dst).Elem().Set(value)
+		found = true
+		return errStopIteration
+	})
+	if err != nil {
+		return errors.Wrap(err, "s.each")
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// checkModel makes sure a model can be written by the store
+func (s *store) checkModel(model Model) error {
+	if model.Kind() != s.model.Kind() {
+		return errors.Errorf("cannot save a %s record in a %s store", model.Kind(), s.model.Kind())
+	}
+	if len(model.GetKey()) == 0 {
+		return errors.New("record has no key")
+	}
+
+	return nil
+}
+
+// Save creates or replaces a record, in the bucket and under the key given by the model itself
+func (s *store) Save(ctx context.Context, model Model) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+	if model == nil {
+		return errors.New("model cannot be nil")
+	}
+	if err := s.checkModel(model); err != nil {
+		return errors.Wrap(err, "s.checkModel")
+	}
+
+	data, err := json.Marshal(model)
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	if err := s.session.Store(model.Kind(), model.GetKey(), data); err != nil {
+		return errors.Wrap(err, "db.Store")
+	}
+
+	return nil
+}
+
+// Delete removes a record. ErrNotFound is returned if it does not exist.
+func (s *store) Delete(ctx context.Context, id string) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+
+	bucketName := s.model.Kind()
+	err := s.db().Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.Errorf("bucket %s does not exist", bucketName)
+		}
+		if b.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+
+		return s.session.delete(tx, bucketName, []byte(id))
+	})
+	if err != nil {
+		return errors.Wrap(err, "Database.Update")
+	}
+
+	return nil
+}
+
+// Update atomically reads a record, passes a pointer to it to fn and saves the result, all in a single
+// transaction. Nothing is written if fn returns an error, which is returned as is. fn must not change
+// the record key and must not use the database, as the transaction holds its write lock.
+func (s *store) Update(ctx context.Context, id string, fn func(dst interface{}) error) error {
+	if s.db() == nil {
+		return errors.New("database was not initialized")
+	}
+
+	bucketName := s.model.Kind()
+	var fnErr error
+	err := s.db().Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(bucketName))
+		if b == nil {
+			return errors.Errorf("bucket %s does not exist", bucketName)
+		}
+		v := b.Get([]byte(id))
+		if v == nil {
+			return ErrNotFound
+		}
+
+		record := reflect.New(modelType(s.model))
+		if err := json.Unmarshal(v, record.Interface()); err != nil {
+			return errors.Wrap(err, "json.Unmarshal")
+		}
+
+		if fnErr = fn(record.Interface()); fnErr != nil {
+			return fnErr
+		}
+
+		model, ok := record.Interface().(Model)
+		if !ok {
+			return errors.Errorf("%s does not implement Model", record.Type())
+		}
+		if string(model.GetKey()) != id {
+			return errors.Errorf("record key changed from %s to %s", id, model.GetKey())
+		}
+
+		data, err := json.Marshal(record.Interface())
+		if err != nil {
+			return errors.Wrap(err, "json.Marshal")
+		}
+
+		return s.session.put(tx, bucketName, []byte(id), data)
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return errors.Wrap(err, "Database.Update")
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestStore_Write(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.RegisterIndexes(&IndexedStruct{}); err != nil {
+		t.Fatalf("db.RegisterIndexes: %s", err)
+	}
+	store := NewStore(db, &IndexedStruct{})
+	ctx := context.Background()
+
+	if err := store.Save(ctx, &IndexedStruct{}); err == nil {
+		t.Error("expected an error saving a record without key")
+	}
+	if err := store.Save(ctx, TestStruct{ID: "x"}); err == nil {
+		t.Error("expected an error saving a record of another kind")
+	}
+
+	for _, v := range []IndexedStruct{{ID: "a", Tags: []string{"one"}}, {ID: "b", Tags: []string{"two"}}} {
+		v := v
+		if err := store.Save(ctx, &v); err != nil {
+			t.Fatalf("store.Save: %s", err)
+		}
+	}
+
+	var found IndexedStruct
+	if err := store.FindOne(ctx, &found, Filter{"ID": {Operator: Equal, Value: "b"}}); err != nil {
+		t.Fatalf("store.FindOne: %s", err)
+	}
+	if found.ID != "b" {
+		t.Errorf("expected b, got %s", found.ID)
+	}
+	if err := store.FindOne(ctx, &found, Filter{"ID": {Operator: Equal, Value: "z"}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	err := store.Update(ctx, "a", func(dst interface{}) error {
+		v := dst.(*IndexedStruct)
+		v.Tags = append(v.Tags, "three")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("store.Update: %s", err)
+	}
+	if err := store.FindByIndex(ctx, &found, "tag", "three"); err != nil || found.ID != "a" {
+		t.Errorf("expected the update to be indexed, got %s, %v", found.ID, err)
+	}
+
+	abort := errors.New("abort")
+	err = store.Update(ctx, "a", func(dst interface{}) error {
+		dst.(*IndexedStruct).Tags = nil
+		return abort
+	})
+	if err != abort {
+		t.Errorf("expected the function error, got %v", err)
+	}
+	if err := store.FindByID(ctx, &found, "a"); err != nil || len(found.Tags) != 2 {
+		t.Errorf("an aborted update should not be written, got %+v, %v", found, err)
+	}
+
+	err = store.Update(ctx, "a", func(dst interface{}) error {
+		dst.(*IndexedStruct).ID = "c"
+		return nil
+	})
+	if err == nil {
+		t.Error("expected an error when the key changes")
+	}
+	if err := store.Update(ctx, "z", func(dst interface{}) error { return nil }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Delete(ctx, "a"); err != nil {
+		t.Fatalf("store.Delete: %s", err)
+	}
+	if err := store.FindByID(ctx, &found, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the record to be deleted, got %v", err)
+	}
+	if err := store.FindByIndex(ctx, &found, "tag", "one"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected the index entry to be deleted, got %v", err)
+	}
+	if err := store.Delete(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
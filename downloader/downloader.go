@@ -0,0 +1,45 @@
+package downloader
+
+type JobStatus string
+
+const (
+	JobQueued      JobStatus = "queued"
+	JobPaused      JobStatus = "paused"
+	JobDownloading JobStatus = "downloading"
+	JobProcessing  JobStatus = "processing" // Download is finished, post-processing (repair, unpack) is running
+	JobCompleted   JobStatus = "completed"
+	JobFailed      JobStatus = "failed"
+	JobDeleted     JobStatus = "deleted" // Removed by hand from the downloader, which is not a failure
+)
+
+// Job is a download, either still in the queue or already in the history of a downloader
+type Job struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Status      JobStatus `json:"status"`
+	Progress    float64   `json:"progress"` // From 0 to 100
+	StoragePath string    `json:"storage_path,omitempty"`
+	FailMessage string    `json:"fail_message,omitempty"`
+}
+
+// Downloader is a usenet client able to download nzb files
+type Downloader interface {
+	// Name identifies the downloader in logs and api responses
+	Name() string
+
+	// AddByURL makes the downloader fetch the nzb at the given url. It returns the id of the new job.
+	AddByURL(url string, name string) (string, error)
+	// AddByContent uploads an nzb file to the downloader. It returns the id of the new job.
+	AddByContent(filename string, content []byte) (string, error)
+
+	// QueueJobs lists the jobs still being downloaded. If ids are given, only those jobs are returned.
+	QueueJobs(ids ...string) ([]Job, error)
+	// HistoryJobs lists finished and post-processing jobs. If ids are given, only those jobs are returned.
+	HistoryJobs(ids ...string) ([]Job, error)
+
+	Pause() error
+	Resume() error
+
+	// Delete removes a job from either the queue or the history
+	Delete(id string, deleteFiles bool) error
+}
@@ -0,0 +1,292 @@
+package importer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type Mode string
+
+const (
+	ModeMove     Mode = "move"
+	ModeCopy     Mode = "copy"
+	ModeHardlink Mode = "hardlink"
+)
+
+// DefaultTemplate is the naming template used when none is configured
+const DefaultTemplate = "{Title} ({Year})/{Title} ({Year}) [{Quality}].{ext}"
+
+// VideoExtensions are the file extensions considered when looking for the movie file
+var VideoExtensions = []string{".mkv", ".mp4", ".avi", ".m4v", ".mov", ".wmv", ".ts", ".m2ts", ".mpg", ".mpeg"}
+
+// extraFolders are folders holding bonus material instead of the movie itself
+var extraFolders = []string{"sample", "samples", "extras", "extra", "featurettes", "behind the scenes", "deleted scenes", "interviews", "trailers", "shorts", "scenes"}
+
+var sampleFile = regexp.MustCompile(`(?i)(^|[^a-z0-9])sample([^a-z0-9]|$)`)
+
+// Naming holds the values available to the naming template
+type Naming struct {
+	Title   string
+	Year    int
+	Quality string
+	ImdbId  string
+	Edition string
+	Group   string
+}
+
+type Importer struct {
+	Root     string // Library folder where movies are placed
+	Mode     Mode
+	Template string
+}
+
+type Result struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func New(root string, mode Mode, template string) (Importer, error) {
+	switch mode {
+	case ModeMove, ModeCopy, ModeHardlink:
+	case "":
+		mode = ModeMove
+	default:
+		return Importer{}, errors.Errorf("invalid import mode %q", mode)
+	}
+	if root == "" {
+		return Importer{}, errors.New("library root cannot be empty")
+	}
+	if template == "" {
+		template = DefaultTemplate
+	}
+
+	return Importer{Root: root, Mode: mode, Template: template}, nil
+}
+
+func IsVideo(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range VideoExtensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isExtraFolder(name string) bool {
+	name = strings.ToLower(name)
+	for _, folder := range extraFolders {
+		if name == folder {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindVideo returns the main video file inside source, which is the largest video that is not a sample
+// or extra material. If source is a file, it is returned as long as it is a video.
+func FindVideo(source string) (string, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return "", errors.Wrap(err, "os.Stat")
+	}
+	if !info.IsDir() {
+		if !IsVideo(source) {
+			return "", errors.Errorf("%s is not a video file", source)
+		}
+		return source, nil
+	}
+
+	var best string
+	var bestSize int64
+	err = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != source && isExtraFolder(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !IsVideo(path) || sampleFile.MatchString(strings.TrimSuffix(info.Name(), filepath.Ext(path))) {
+			return nil
+		}
+		if info.Size() > bestSize {
+			best = path
+			bestSize = info.Size()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "filepath.Walk")
+	}
+	if best == "" {
+		return "", errors.Errorf("no video file found in %s", source)
+	}
+
+	return best, nil
+}
+
+var (
+	invalidChars  = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	emptyBrackets = regexp.MustCompile(`\s*(\(\s*\)|\[\s*\]|\{\s*\})`)
+	spaces        = regexp.MustCompile(`\s+`)
+)
+
+// sanitize cleans a single path segment: invalid characters are removed, as are the brackets left
+// empty by missing values, like "()" for a movie without year.
+func sanitize(segment string) string {
+	segment = invalidChars.ReplaceAllString(segment, "")
+	segment = emptyBrackets.ReplaceAllString(segment, "")
+	segment = spaces.ReplaceAllString(segment, " ")
+	segment = strings.Trim(segment, " .")
+
+	return segment
+}
+
+// Path renders the naming template for a file with the given extension. The result is relative to the library root.
+func (i Importer) Path(naming Naming, ext string) (string, error) {
+	year := ""
+	if naming.Year > 0 {
+		year = strconv.Itoa(naming.Year)
+	}
+
+	ext = strings.TrimPrefix(ext, ".")
+	replacer := strings.NewReplacer(
+		"{Title}", naming.Title,
+		"{Year}", year,
+		"{Quality}", naming.Quality,
+		"{ImdbId}", naming.ImdbId,
+		"{Edition}", naming.Edition,
+		"{Group}", naming.Group,
+		"{ext}", ext,
+	)
+
+	var segments []string
+	for _, segment := range strings.Split(i.Template, "/") {
+		// the extension is appended after sanitizing, which would strip the dot before it
+		suffix := ""
+		if strings.HasSuffix(segment, ".{ext}") {
+			segment = strings.TrimSuffix(segment, ".{ext}")
+			suffix = "." + ext
+		}
+
+		rendered := sanitize(replacer.Replace(segment))
+		if rendered == "" {
+			return "", errors.Errorf("template segment %q renders empty", segment)
+		}
+		segments = append(segments, rendered+suffix)
+	}
+
+	return filepath.Join(segments...), nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return errors.Wrap(err, "os.Open")
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return errors.Wrap(err, "os.Create")
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return errors.Wrap(err, "io.Copy")
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrap(err, "out.Close")
+	}
+
+	return nil
+}
+
+// place puts src at dst according to the import mode
+func (i Importer) place(src string, dst string) error {
+	switch i.Mode {
+	case ModeCopy:
+		return copyFile(src, dst)
+	case ModeHardlink:
+		if err := os.Link(src, dst); err != nil {
+			return errors.Wrap(err, "os.Link")
+		}
+		return nil
+	default:
+		if err := os.Rename(src, dst); err == nil {
+			return nil
+		}
+		// rename does not work across file systems
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+		if err := os.Remove(src); err != nil {
+			return errors.Wrap(err, "os.Remove")
+		}
+		return nil
+	}
+}
+
+// Import finds the movie file inside source and places it in the library. An existing file at the
+// destination is replaced.
+func (i Importer) Import(source string, naming Naming) (Result, error) {
+	video, err := FindVideo(source)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "FindVideo")
+	}
+
+	relative, err := i.Path(naming, filepath.Ext(video))
+	if err != nil {
+		return Result{}, errors.Wrap(err, "i.Path")
+	}
+	dst := filepath.Join(i.Root, relative)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0775); err != nil {
+		return Result{}, errors.Wrap(err, "os.MkdirAll")
+	}
+
+	// the file is placed next to the destination, then renamed over it, so a failed import leaves an
+	// existing file untouched
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".import-*"+filepath.Ext(dst))
+	if err != nil {
+		return Result{}, errors.Wrap(err, "ioutil.TempFile")
+	}
+	placed := tmp.Name()
+	_ = tmp.Close()
+	// hardlinks and renames need a free name
+	if err := os.Remove(placed); err != nil {
+		return Result{}, errors.Wrap(err, "os.Remove")
+	}
+
+	if err := i.place(video, placed); err != nil {
+		_ = os.Remove(placed)
+		return Result{}, errors.Wrapf(err, "%s %s", i.Mode, video)
+	}
+	if err := os.Rename(placed, dst); err != nil {
+		// a moved download only exists at the temporary path now
+		if i.Mode != ModeMove {
+			_ = os.Remove(placed)
+		}
+		return Result{}, errors.Wrapf(err, "os.Rename %s", placed)
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return Result{}, errors.Wrap(err, "os.Stat")
+	}
+
+	return Result{Path: dst, Size: info.Size()}, nil
+}
@@ -0,0 +1,168 @@
+package importer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFile creates a file of the given size, creating its parent folders
+func writeFile(t *testing.T, path string, size int) {
+	if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+		t.Fatalf("os.MkdirAll: %s", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Repeat("x", size)), 0664); err != nil {
+		t.Fatalf("ioutil.WriteFile: %s", err)
+	}
+}
+
+func TestFindVideo(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv"), 100)
+	writeFile(t, filepath.Join(dir, "the.matrix.1999.1080p.bluray.x264-grp-sample.mkv"), 500)
+	writeFile(t, filepath.Join(dir, "Sample", "matrix.mkv"), 500)
+	writeFile(t, filepath.Join(dir, "Featurettes", "making.of.mkv"), 500)
+	writeFile(t, filepath.Join(dir, "The.Matrix.1999.1080p.BluRay.x264-GRP.nfo"), 1000)
+
+	video, err := FindVideo(dir)
+	if err != nil {
+		t.Fatalf("FindVideo: %s", err)
+	}
+	if expected := filepath.Join(dir, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv"); video != expected {
+		t.Errorf("expected %s, got %s", expected, video)
+	}
+
+	empty := t.TempDir()
+	writeFile(t, filepath.Join(empty, "readme.txt"), 10)
+	if _, err := FindVideo(empty); err == nil {
+		t.Error("expected an error for a folder without videos")
+	}
+}
+
+func TestImporter_Path(t *testing.T) {
+	testCases := []struct {
+		template string
+		naming   Naming
+		expected string
+	}{
+		{
+			template: DefaultTemplate,
+			naming:   Naming{Title: "The Matrix", Year: 1999, Quality: "1080p BluRay"},
+			expected: "The Matrix (1999)/The Matrix (1999) [1080p BluRay].mkv",
+		},
+		{
+			template: DefaultTemplate,
+			naming:   Naming{Title: "Mission: Impossible", Year: 1996},
+			expected: "Mission Impossible (1996)/Mission Impossible (1996).mkv",
+		},
+		{
+			template: "{Title}/{Title} {Edition} - {Group}.{ext}",
+			naming:   Naming{Title: "AC/DC: Let There Be Rock", Edition: "Remastered", Group: "GRP"},
+			expected: "ACDC Let There Be Rock/ACDC Let There Be Rock Remastered - GRP.mkv",
+		},
+	}
+
+	for _, tc := range testCases {
+		i := Importer{Template: tc.template}
+		path, err := i.Path(tc.naming, ".mkv")
+		if err != nil {
+			t.Errorf("%s: i.Path: %s", tc.expected, err)
+			continue
+		}
+		if path != filepath.FromSlash(tc.expected) {
+			t.Errorf("expected %s, got %s", tc.expected, path)
+		}
+	}
+
+	if _, err := (Importer{Template: "{Year}/{Title}.{ext}"}).Path(Naming{Title: "Unknown"}, ".mkv"); err == nil {
+		t.Error("expected an error for an empty folder name")
+	}
+}
+
+func TestImporter_Import(t *testing.T) {
+	naming := Naming{Title: "The Matrix", Year: 1999, Quality: "1080p BluRay"}
+	expected := filepath.FromSlash("The Matrix (1999)/The Matrix (1999) [1080p BluRay].mkv")
+
+	for _, mode := range []Mode{ModeMove, ModeCopy, ModeHardlink} {
+		download := t.TempDir()
+		source := filepath.Join(download, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv")
+		writeFile(t, source, 100)
+
+		root := t.TempDir()
+		i, err := New(root, mode, "")
+		if err != nil {
+			t.Fatalf("New: %s", err)
+		}
+
+		result, err := i.Import(download, naming)
+		if err != nil {
+			t.Errorf("%s: i.Import: %s", mode, err)
+			continue
+		}
+		if result.Path != filepath.Join(root, expected) || result.Size != 100 {
+			t.Errorf("%s: unexpected result %+v", mode, result)
+		}
+
+		if _, err := os.Stat(result.Path); err != nil {
+			t.Errorf("%s: imported file is missing: %s", mode, err)
+		}
+		_, err = os.Stat(source)
+		if mode == ModeMove && !os.IsNotExist(err) {
+			t.Errorf("%s: source should have been removed", mode)
+		}
+		if mode != ModeMove && err != nil {
+			t.Errorf("%s: source should have been kept: %s", mode, err)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(t.TempDir(), "symlink", ""); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+	if _, err := New("", ModeMove, ""); err == nil {
+		t.Error("expected an error for an empty root")
+	}
+
+	i, err := New(t.TempDir(), "", "")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	if i.Mode != ModeMove || i.Template != DefaultTemplate {
+		t.Errorf("unexpected defaults: %+v", i)
+	}
+}
+
+func TestImporter_ImportFailureKeepsExistingFile(t *testing.T) {
+	naming := Naming{Title: "The Matrix", Year: 1999, Quality: "1080p BluRay"}
+
+	root := t.TempDir()
+	i, err := New(root, ModeCopy, "")
+	if err != nil {
+		t.Fatalf("New: %s", err)
+	}
+	existing := filepath.Join(root, "The Matrix (1999)", "The Matrix (1999) [1080p BluRay].mkv")
+	writeFile(t, existing, 100)
+
+	// a dangling link is found as the video, but cannot be copied
+	download := t.TempDir()
+	if err := os.Symlink(filepath.Join(download, "missing"), filepath.Join(download, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv")); err != nil {
+		t.Fatalf("os.Symlink: %s", err)
+	}
+	if _, err := i.Import(download, naming); err == nil {
+		t.Fatalf("expected the import to fail")
+	}
+
+	if info, err := os.Stat(existing); err != nil || info.Size() != 100 {
+		t.Errorf("existing file should be kept: %v", err)
+	}
+	entries, err := ioutil.ReadDir(filepath.Dir(existing))
+	if err != nil {
+		t.Fatalf("ioutil.ReadDir: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary file was left behind: %d entries", len(entries))
+	}
+}
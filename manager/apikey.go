@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"pomegranate/models"
+
+	"github.com/pkg/errors"
+)
+
+func generateApiKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "rand.Read")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// ensureApiKey generates the api key on first start
+func (m *Manager) ensureApiKey() error {
+	key, err := m.ApiKey()
+	if err != nil {
+		return errors.Wrap(err, "m.ApiKey")
+	}
+	if key != "" {
+		return nil
+	}
+
+	key, err = m.RotateApiKey()
+	if err != nil {
+		return errors.Wrap(err, "m.RotateApiKey")
+	}
+	// printed once, to stdout rather than the log: it is the only way to learn the first key
+	fmt.Printf("generated api key: %s\n", key)
+
+	return nil
+}
+
+// ApiKey returns the key clients must send to use the http service
+func (m *Manager) ApiKey() (string, error) {
+	key, err := m.DB.Read([]byte(models.SettingsKind), []byte(models.ApiKeySetting))
+	if err != nil {
+		return "", errors.Wrap(err, "m.DB.Read")
+	}
+
+	return string(key), nil
+}
+
+// RotateApiKey replaces the api key with a new random one, which is returned
+func (m *Manager) RotateApiKey() (string, error) {
+	key, err := generateApiKey()
+	if err != nil {
+		return "", errors.Wrap(err, "generateApiKey")
+	}
+
+	if err := m.DB.Store(models.SettingsKind, []byte(models.ApiKeySetting), []byte(key)); err != nil {
+		return "", errors.Wrap(err, "m.DB.Store")
+	}
+
+	return key, nil
+}
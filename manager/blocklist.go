@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pomegranate/models"
+	"pomegranate/newznab"
+
+	"github.com/pkg/errors"
+)
+
+// blocklistKey is the key of a release in the blocklist. Releases without a GUID are keyed by their url.
+func blocklistKey(guid string, url string) string {
+	if guid != "" {
+		return guid
+	}
+
+	return url
+}
+
+func (m *Manager) IsBlocklisted(guid string, url string) (bool, error) {
+	data, err := m.DB.Read([]byte(models.BlocklistKind), []byte(blocklistKey(guid, url)))
+	if err != nil {
+		return false, errors.Wrap(err, "m.DB.Read")
+	}
+
+	return data != nil, nil
+}
+
+// withoutBlocklisted removes every blocklisted release from a list of search results
+func (m *Manager) withoutBlocklisted(items []newznab.SearchResponseItem) ([]newznab.SearchResponseItem, error) {
+	var resp []newznab.SearchResponseItem
+	for _, item := range items {
+		blocked, err := m.IsBlocklisted(item.GUID, item.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "m.IsBlocklisted")
+		}
+		if !blocked {
+			resp = append(resp, item)
+		}
+	}
+
+	return resp, nil
+}
+
+func (m *Manager) AddToBlocklist(movie models.Movie, nzb models.NzbInfo, reason string) error {
+	entry := models.BlocklistEntry{
+		GUID:      blocklistKey(nzb.GUID, nzb.URL),
+		Title:     nzb.Title,
+		ImdbId:    movie.ImdbId,
+		Reason:    reason,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err := m.Blocklisted.Save(context.Background(), &entry); err != nil {
+		return errors.Wrap(err, "m.Blocklisted.Save")
+	}
+
+	return nil
+}
+
+func (m *Manager) Blocklist() ([]models.BlocklistEntry, error) {
+	var resp []models.BlocklistEntry
+
+	if err := m.Blocklisted.FindAll(context.Background(), &resp); err != nil {
+		return nil, errors.Wrap(err, "m.Blocklisted.FindAll")
+	}
+
+	return resp, nil
+}
+
+// RemoveFromBlocklist removes a single release from the blocklist
+func (m *Manager) RemoveFromBlocklist(guid string) error {
+	if err := m.DB.Delete(models.BlocklistKind, []byte(guid)); err != nil {
+		return errors.Wrap(err, "m.DB.Delete")
+	}
+
+	return nil
+}
+
+func (m *Manager) ClearBlocklist() error {
+	if err := m.DB.ClearBucket(models.BlocklistKind); err != nil {
+		return errors.Wrap(err, "m.DB.ClearBucket")
+	}
+
+	return nil
+}
+
+// HandleFailedDownload reacts to a release that failed to download: the release is marked as failed
+// and blocklisted, the job is optionally removed from the downloader, and the next best release is grabbed.
+func (m *Manager) HandleFailedDownload(movie *models.Movie, nzbID string) error {
+	err := m.Movies.Update(context.Background(), movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		nzb := stored.Release(nzbID)
+		if nzb == nil {
+			return errors.Errorf("movie %s has no release %s", movie.ImdbId, nzbID)
+		}
+
+		nzb.Status = models.StatusFailed
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "m.Movies.Update")
+	}
+	nzb := movie.Release(nzbID)
+
+	if m.DeleteFailed && m.Downloader != nil && nzb.DownloaderId != "" {
+		if err := m.Downloader.Delete(nzb.DownloaderId, true); err != nil {
+			log.Printf("cannot delete failed job %s from %s: %s\n", nzb.DownloaderId, m.Downloader.Name(), err)
+		}
+	}
+
+	reason := nzb.FailMessage
+	if reason == "" {
+		reason = "download failed"
+	}
+	if blocklistKey(nzb.GUID, nzb.URL) == "" {
+		log.Printf("release %s of %s has no guid nor url and cannot be blocklisted\n", nzb.ID, movie.ImdbId)
+	} else if err := m.AddToBlocklist(*movie, *nzb, reason); err != nil {
+		return errors.Wrap(err, "m.AddToBlocklist")
+	}
+
+	next, err := m.grabBest(movie, models.ActorSystem)
+	if err != nil {
+		return errors.Wrap(err, "m.grabBest")
+	}
+	if next == nil {
+		log.Printf("no other release available for %s\n", movie.ImdbId)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"pomegranate/sabnzbd"
+	"strings"
+	"testing"
+)
+
+func TestManager_HandleFailedDownload(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	var deleted, added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch query.Get("mode") {
+		case "queue", "history":
+			if query.Get("name") == "delete" {
+				deleted = append(deleted, query.Get("mode")+":"+query.Get("value"))
+			}
+			_, _ = w.Write([]byte(`{"status": true, "nzo_ids": []}`))
+		case "addurl":
+			added = append(added, query.Get("name"))
+			_, _ = w.Write([]byte(`{"status": true, "nzo_ids": ["nzo_next"]}`))
+		default:
+			t.Errorf("unexpected mode: %s", query.Get("mode"))
+		}
+	}))
+	defer server.Close()
+	m.Downloader = sabnzbd.New(strings.TrimPrefix(server.URL, "http://"), "")
+	m.DeleteFailed = true
+
+	movie := models.Movie{
+		ImdbId: "tt0133093",
+		Title:  "The Matrix",
+		NzbInfo: []models.NzbInfo{
+			{ID: "failed", GUID: "guid-failed", URL: "http://indexer/failed", Title: "The.Matrix.1999.1080p.BluRay.x264-GRP", Status: models.StatusSnatched, DownloaderId: "nzo_failed", FailMessage: "CRC error"},
+			{ID: "next", GUID: "guid-next", URL: "http://indexer/next", Title: "The.Matrix.1999.720p.BluRay.x264-GRP", Status: models.StatusUnknown},
+		},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	if err := m.HandleFailedDownload(&movie, "failed"); err != nil {
+		t.Fatalf("m.HandleFailedDownload: %s", err)
+	}
+
+	if len(deleted) != 2 || deleted[0] != "queue:nzo_failed" || deleted[1] != "history:nzo_failed" {
+		t.Errorf("failed job was not deleted from sabnzbd: %v", deleted)
+	}
+	if len(added) != 1 || added[0] != "http://indexer/next" {
+		t.Errorf("next release was not grabbed: %v", added)
+	}
+
+	blocked, err := m.IsBlocklisted("guid-failed", "")
+	if err != nil {
+		t.Fatalf("m.IsBlocklisted: %s", err)
+	}
+	if !blocked {
+		t.Errorf("failed release should be blocklisted")
+	}
+
+	updated, err := m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if status := updated.Release("failed").Status; status != models.StatusFailed {
+		t.Errorf("unexpected status for failed release: %s", status)
+	}
+	if next := updated.Release("next"); next.Status != models.StatusSnatched || next.DownloaderId != "nzo_next" {
+		t.Errorf("unexpected state for next release: %+v", *next)
+	}
+
+	// blocklisted releases are not merged again
+	items, err := m.withoutBlocklisted([]newznab.SearchResponseItem{{GUID: "guid-failed"}, {GUID: "guid-new"}})
+	if err != nil {
+		t.Fatalf("m.withoutBlocklisted: %s", err)
+	}
+	if len(items) != 1 || items[0].GUID != "guid-new" {
+		t.Errorf("unexpected search results: %v", items)
+	}
+
+	if err := m.ClearBlocklist(); err != nil {
+		t.Fatalf("m.ClearBlocklist: %s", err)
+	}
+	entries, err := m.Blocklist()
+	if err != nil {
+		t.Fatalf("m.Blocklist: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("blocklist should be empty after clearing it: %v", entries)
+	}
+}
@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"pomegranate/release"
+
+	"github.com/pkg/errors"
+)
+
+// capsMaxAge is how long the capabilities of an indexer are cached before being queried again
+const capsMaxAge = 24 * time.Hour
+
+// IndexerCaps returns the capabilities of an indexer, queried with t=caps when the cached ones are
+// missing or older than capsMaxAge. Stale capabilities are returned when the indexer cannot be reached.
+func (m *Manager) IndexerCaps(ctx context.Context, n newznab.Newznab) (newznab.Caps, error) {
+	var cached models.IndexerCaps
+	err := m.Capabilities.FindByID(ctx, &cached, n.Host)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return newznab.Caps{}, errors.Wrap(err, "m.Capabilities.FindByID")
+	}
+	found := err == nil
+	if found && time.Since(cached.FetchedAt) < capsMaxAge {
+		return cached.Caps, nil
+	}
+
+	caps, err := n.Caps(ctx)
+	if err != nil {
+		if found {
+			log.Printf("cannot refresh capabilities of %s, using the ones from %s: %s\n", n.Host, cached.FetchedAt.Format(time.RFC3339), err)
+			return cached.Caps, nil
+		}
+		return newznab.Caps{}, errors.Wrap(upstream(n.Host, err), "newznab.Caps")
+	}
+
+	entry := models.IndexerCaps{Host: n.Host, Caps: caps, FetchedAt: time.Now().UTC()}
+	if err := m.Capabilities.Save(ctx, &entry); err != nil {
+		return caps, errors.Wrap(err, "m.Capabilities.Save")
+	}
+
+	return caps, nil
+}
+
+// movieCategories returns the categories text searches are restricted to: the movies tree, unless the
+// indexer lists categories without it
+func movieCategories(caps newznab.Caps) []int {
+	if len(caps.Categories) == 0 {
+		return []int{newznab.CategoryMovies}
+	}
+	for _, category := range caps.Categories {
+		if category.ID == newznab.CategoryMovies {
+			return []int{newznab.CategoryMovies}
+		}
+	}
+
+	return nil
+}
+
+// containsTitle reports whether a title is in the list, once normalized
+func containsTitle(titles []string, title string) bool {
+	for _, t := range titles {
+		if normalizeTitle(t) == normalizeTitle(title) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchesMovie reports whether a release found by a text search is for the given movie, under its title
+// or one of its alternative titles
+func matchesMovie(movie models.Movie, title string) bool {
+	info := release.Parse(title)
+	if !containsTitle(append([]string{movie.Title}, movie.AlternativeTitles...), info.Title) {
+		return false
+	}
+
+	year := movie.Year()
+	return year == 0 || info.Year == 0 || info.Year == year
+}
@@ -0,0 +1,155 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"strings"
+	"testing"
+)
+
+const textOnlyCaps = `<caps>
+  <searching>
+    <search available="yes" supportedParams="q" />
+    <movie-search available="no" supportedParams="q,imdbid" />
+  </searching>
+</caps>`
+
+const textSearchResults = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+  <item>
+    <title>The.Matrix.1999.1080p.BluRay.x264-GRP</title>
+    <guid>guid-matrix</guid>
+    <enclosure url="http://indexer/matrix" length="100" type="application/x-nzb" />
+  </item>
+  <item>
+    <title>The.Matrix.Reloaded.2003.1080p.BluRay.x264-GRP</title>
+    <guid>guid-reloaded</guid>
+    <enclosure url="http://indexer/reloaded" length="100" type="application/x-nzb" />
+  </item>
+</channel>
+</rss>`
+
+func TestManager_SearchReleasesTextFallback(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	var capsRequests int
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch query.Get("t") {
+		case "caps":
+			capsRequests++
+			_, _ = w.Write([]byte(textOnlyCaps))
+		case "search":
+			if query.Get("offset") == "" {
+				queries = append(queries, query.Get("q"))
+			}
+			_, _ = w.Write([]byte(textSearchResults))
+		default:
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+	}))
+	defer server.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(server.URL, "http://")}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix", ReleaseDate: "1999-03-30"}
+	for i := 0; i < 2; i++ {
+		added, err := m.SearchReleases(context.Background(), &movie)
+		if err != nil {
+			t.Fatalf("m.SearchReleases: %s", err)
+		}
+		if i == 0 && added != 1 {
+			t.Errorf("expected 1 release added, got %d", added)
+		}
+	}
+
+	if capsRequests != 1 {
+		t.Errorf("capabilities should be cached, got %d requests", capsRequests)
+	}
+	if len(queries) != 2 || queries[0] != "The Matrix 1999" {
+		t.Errorf("unexpected text queries: %v", queries)
+	}
+	if len(movie.NzbInfo) != 1 || movie.NzbInfo[0].GUID != "guid-matrix" {
+		t.Errorf("unexpected releases: %+v", movie.NzbInfo)
+	}
+}
+
+const imdbSearchResults = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+  <newznab:response offset="0" total="1" />
+  <item>
+    <title>The.Matrix.1999.1080p.BluRay.x264-GRP</title>
+    <guid>guid-matrix</guid>
+    <enclosure url="http://indexer/matrix" length="100" type="application/x-nzb" />
+  </item>
+</channel>
+</rss>`
+
+const alternativeSearchResults = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel>
+  <newznab:response offset="0" total="1" />
+  <item>
+    <title>Matrix.1999.MULTi.1080p.BluRay.x264-OTHER</title>
+    <guid>guid-alternative</guid>
+    <enclosure url="http://indexer/alternative" length="100" type="application/x-nzb" />
+  </item>
+</channel>
+</rss>`
+
+const capsResponseBoth = `<caps>
+  <searching>
+    <search available="yes" supportedParams="q" />
+    <movie-search available="yes" supportedParams="q,imdbid" />
+  </searching>
+  <categories>
+    <category id="2000" name="Movies" />
+  </categories>
+</caps>`
+
+func TestManager_SearchReleasesCombined(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		requests = append(requests, query.Get("t")+":"+query.Get("imdbid")+query.Get("q"))
+		switch {
+		case query.Get("t") == "caps":
+			_, _ = w.Write([]byte(capsResponseBoth))
+		case query.Get("t") == "movie":
+			_, _ = w.Write([]byte(imdbSearchResults))
+		case query.Get("q") == "Matrix 1999":
+			_, _ = w.Write([]byte(alternativeSearchResults))
+		default:
+			if query.Get("offset") != "" {
+				_, _ = w.Write([]byte(`<rss version="2.0"><channel></channel></rss>`))
+				return
+			}
+			_, _ = w.Write([]byte(textSearchResults))
+		}
+	}))
+	defer server.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(server.URL, "http://")}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix", AlternativeTitles: []string{"Matrix", "the matrix"}, ReleaseDate: "1999-03-30"}
+	added, err := m.SearchReleases(context.Background(), &movie)
+	if err != nil {
+		t.Fatalf("m.SearchReleases: %s", err)
+	}
+
+	if added != 2 || len(movie.NzbInfo) != 2 || movie.NzbInfo[0].GUID != "guid-matrix" || movie.NzbInfo[1].GUID != "guid-alternative" {
+		t.Errorf("unexpected releases: %+v", movie.NzbInfo)
+	}
+	expected := []string{"caps:", "movie:0133093", "search:The Matrix 1999", "search:The Matrix 1999", "search:Matrix 1999"}
+	if strings.Join(requests, "|") != strings.Join(expected, "|") {
+		t.Errorf("unexpected requests: %v", requests)
+	}
+}
@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrInvalid is returned when the caller provides bad input, like a malformed id or an unknown profile
+	ErrInvalid = errors.New("invalid input")
+	// ErrAlreadyExists is returned when creating something that is already in the database
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrQuotaExhausted is returned when an indexer reached its daily api or grab limit
+	ErrQuotaExhausted = errors.New("indexer quota exhausted")
+)
+
+// UpstreamError is a failure of an external service, like themoviedb, an indexer or the downloader
+type UpstreamError struct {
+	Service string
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	return e.Service + ": " + e.Err.Error()
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func upstream(service string, err error) error {
+	return &UpstreamError{Service: service, Err: err}
+}
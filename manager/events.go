@@ -0,0 +1,151 @@
+package manager
+
+import (
+	"sync"
+	"time"
+
+	"pomegranate/models"
+)
+
+type EventType string
+
+// Events mirroring the history share the names of models.HistoryEvent
+const (
+	EventMovieAdded        EventType = EventType(models.EventMovieAdded)
+	EventReleasesFound     EventType = EventType(models.EventReleasesFound)
+	EventReleaseGrabbed    EventType = EventType(models.EventReleaseGrabbed)
+	EventDownloadCompleted EventType = EventType(models.EventDownloadCompleted)
+	EventDownloadFailed    EventType = EventType(models.EventDownloadFailed)
+	EventMovieImported     EventType = EventType(models.EventMovieImported)
+	EventMovieDeleted      EventType = EventType(models.EventMovieDeleted)
+	EventSearchCompleted   EventType = "search_completed"
+	EventDownloadProgress  EventType = "download_progress"
+)
+
+const (
+	// eventBufferSize is the number of recent events kept to replay to reconnecting subscribers
+	eventBufferSize = 256
+	// subscriberBufferSize is the number of events a subscriber may lag behind before being dropped
+	subscriberBufferSize = 64
+)
+
+type Event struct {
+	ID       uint64    `json:"id"`
+	Type     EventType `json:"type"`
+	ImdbId   string    `json:"imdb_id,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	NzbID    string    `json:"nzb_id,omitempty"`
+	Progress float64   `json:"progress,omitempty"` // Download progress, from 0 to 100
+	Message  string    `json:"message,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// EventBus broadcasts events to in-process subscribers. Recent events are kept so a subscriber
+// reconnecting with the id of the last event it saw gets the ones it missed.
+type EventBus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	recent      []Event
+	subscribers map[chan Event]struct{}
+	closed      bool
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Publish assigns the next id to an event and sends it to every subscriber. Subscribers too slow to
+// keep up are dropped, their channel closed, rather than blocking the publisher.
+func (b *EventBus) Publish(event Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return event
+	}
+
+	b.lastID++
+	event.ID = b.lastID
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	b.recent = append(b.recent, event)
+	if len(b.recent) > eventBufferSize {
+		b.recent = b.recent[len(b.recent)-eventBufferSize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return event
+}
+
+// Subscribe returns a channel receiving every event published from now on, after the recent events
+// following lastID. A lastID of 0 replays nothing; an id greater than the last published one comes
+// from before a restart, and replays every recent event.
+// The channel is closed when the bus closes or the subscriber falls behind; cancel must be called
+// once the subscriber is done.
+func (b *EventBus) Subscribe(lastID uint64) (events <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastID > b.lastID {
+		replay = b.recent
+	} else if lastID > 0 {
+		for i, event := range b.recent {
+			if event.ID > lastID {
+				replay = b.recent[i:]
+				break
+			}
+		}
+	}
+
+	ch := make(chan Event, len(replay)+subscriberBufferSize)
+	for _, event := range replay {
+		ch <- event
+	}
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subscribers[ch] = struct{}{}
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends every subscription. Events published afterwards are dropped.
+func (b *EventBus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+// publish sends a movie event on the bus
+func (m *Manager) publish(eventType EventType, movie models.Movie, nzbID string, message string) {
+	if m.Events == nil {
+		return
+	}
+
+	m.Events.Publish(Event{Type: eventType, ImdbId: movie.ImdbId, Title: movie.Title, NzbID: nzbID, Message: message})
+}
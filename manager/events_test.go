@@ -0,0 +1,73 @@
+package manager
+
+import (
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+
+	first := bus.Publish(Event{Type: EventMovieAdded, ImdbId: "tt0133093"})
+	bus.Publish(Event{Type: EventSearchCompleted, ImdbId: "tt0133093"})
+	if first.ID != 1 || first.Time.IsZero() {
+		t.Errorf("unexpected published event: %+v", first)
+	}
+
+	testCases := []struct {
+		lastID   uint64
+		expected []uint64
+	}{
+		{lastID: 0, expected: nil},
+		{lastID: 1, expected: []uint64{2}},
+		{lastID: 2, expected: nil},
+		{lastID: 100, expected: []uint64{1, 2}}, // from before a restart
+	}
+	for _, tc := range testCases {
+		events, cancel := bus.Subscribe(tc.lastID)
+		var ids []uint64
+		for len(ids) < len(tc.expected) {
+			ids = append(ids, (<-events).ID)
+		}
+		select {
+		case event := <-events:
+			t.Errorf("last id %d: unexpected event %+v", tc.lastID, event)
+		default:
+		}
+		cancel()
+		for i := range tc.expected {
+			if ids[i] != tc.expected[i] {
+				t.Errorf("last id %d: expected %v, got %v", tc.lastID, tc.expected, ids)
+				break
+			}
+		}
+	}
+
+	t.Run("SlowSubscriber", func(t *testing.T) {
+		events, cancel := bus.Subscribe(0)
+		defer cancel()
+
+		for i := 0; i <= subscriberBufferSize; i++ {
+			bus.Publish(Event{Type: EventDownloadProgress})
+		}
+		count := 0
+		for range events {
+			count++
+		}
+		if count != subscriberBufferSize {
+			t.Errorf("expected %d events before being dropped, got %d", subscriberBufferSize, count)
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		events, cancel := bus.Subscribe(0)
+		defer cancel()
+
+		bus.Close()
+		if _, ok := <-events; ok {
+			t.Errorf("expected the channel to be closed")
+		}
+		if event := bus.Publish(Event{Type: EventMovieDeleted}); event.ID != 0 {
+			t.Errorf("events published after Close should be dropped, got %+v", event)
+		}
+	})
+}
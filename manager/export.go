@@ -0,0 +1,336 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/models"
+
+	"github.com/pkg/errors"
+)
+
+// ExportVersion is the version of the export document format. Bump it when the document changes in a way
+// older versions cannot import.
+const ExportVersion = 1
+
+// ExportDocument is a human-readable snapshot of the whole library. Release (nzb) information is part of
+// each movie. Secondary indexes are not exported: they are rebuilt as records are imported.
+// The api key is a credential of the instance: it is neither exported nor imported.
+type ExportDocument struct {
+	Version        int                     `json:"version"`
+	SchemaVersion  int                     `json:"schema_version"` // Database schema version of the exporting instance
+	ExportedAt     time.Time               `json:"exported_at"`
+	Movies         []models.Movie          `json:"movies"`
+	Profiles       []models.QualityProfile `json:"profiles"`
+	Blocklist      []models.BlocklistEntry `json:"blocklist"`
+	PendingImports []models.PendingImport  `json:"pending_imports"`
+	History        []models.HistoryEntry   `json:"history"`
+	Settings       map[string]string       `json:"settings"`
+}
+
+type ImportMode string
+
+const (
+	// ImportMerge creates and updates the imported records, leaving the others alone
+	ImportMerge ImportMode = "merge"
+	// ImportReplace also deletes the records missing from the document
+	ImportReplace ImportMode = "replace"
+)
+
+type ImportOptions struct {
+	Mode   ImportMode // ImportMerge when empty
+	DryRun bool       // Only report what would change
+}
+
+// ImportChanges lists the keys an import creates, updates or deletes in a bucket
+type ImportChanges struct {
+	Created   []string `json:"created,omitempty"`
+	Updated   []string `json:"updated,omitempty"`
+	Deleted   []string `json:"deleted,omitempty"`
+	Unchanged int      `json:"unchanged"`
+}
+
+type ImportReport struct {
+	Mode    ImportMode                `json:"mode"`
+	DryRun  bool                      `json:"dry_run"`
+	Changes map[string]*ImportChanges `json:"changes"` // By bucket
+}
+
+// Export reads every movie, profile, blocklist entry, pending import, history entry and setting
+func (m *Manager) Export() (ExportDocument, error) {
+	ctx := context.Background()
+	doc := ExportDocument{
+		Version:        ExportVersion,
+		SchemaVersion:  database.SchemaVersion(),
+		ExportedAt:     time.Now().UTC(),
+		Movies:         []models.Movie{},
+		Profiles:       []models.QualityProfile{},
+		Blocklist:      []models.BlocklistEntry{},
+		PendingImports: []models.PendingImport{},
+		History:        []models.HistoryEntry{},
+		Settings:       make(map[string]string),
+	}
+
+	if err := m.Movies.FindAll(ctx, &doc.Movies); err != nil {
+		return doc, errors.Wrap(err, "m.Movies.FindAll")
+	}
+	if err := m.Profiles.FindAll(ctx, &doc.Profiles); err != nil {
+		return doc, errors.Wrap(err, "m.Profiles.FindAll")
+	}
+	if err := m.Blocklisted.FindAll(ctx, &doc.Blocklist); err != nil {
+		return doc, errors.Wrap(err, "m.Blocklisted.FindAll")
+	}
+	if err := m.Pending.FindAll(ctx, &doc.PendingImports); err != nil {
+		return doc, errors.Wrap(err, "m.Pending.FindAll")
+	}
+	if err := m.History.FindAll(ctx, &doc.History); err != nil {
+		return doc, errors.Wrap(err, "m.History.FindAll")
+	}
+
+	keys, err := m.DB.BucketKeys(models.SettingsKind)
+	if err != nil {
+		return doc, errors.Wrap(err, "m.DB.BucketKeys")
+	}
+	for _, key := range keys {
+		if privateSettings[string(key)] {
+			continue
+		}
+		value, err := m.DB.Read([]byte(models.SettingsKind), key)
+		if err != nil {
+			return doc, errors.Wrap(err, "m.DB.Read")
+		}
+		doc.Settings[string(key)] = string(value)
+	}
+
+	return doc, nil
+}
+
+// privateSettings are the settings left out of exports, and left alone by imports
+var privateSettings = map[string]bool{
+	models.ApiKeySetting: true,
+}
+
+// importRecord is a record of the document, with the data it is stored as
+type importRecord struct {
+	key   string
+	data  []byte
+	model database.Model // nil for settings, which are stored as is
+}
+
+// importSection holds the records of the document going into a bucket
+type importSection struct {
+	kind    string
+	records []importRecord
+	save    func(record importRecord) error
+	delete  func(key string) error
+	private map[string]bool // Keys neither written nor deleted
+}
+
+func modelRecords(list []database.Model) ([]importRecord, error) {
+	var records []importRecord
+	for _, model := range list {
+		key := string(model.GetKey())
+		if key == "" {
+			return nil, errors.Wrapf(ErrInvalid, "%s record without a key", model.Kind())
+		}
+		data, err := json.Marshal(model)
+		if err != nil {
+			return nil, errors.Wrap(err, "json.Marshal")
+		}
+		records = append(records, importRecord{key: key, data: data, model: model})
+	}
+
+	return records, nil
+}
+
+func storeSection(kind string, store database.Store, list []database.Model) (importSection, error) {
+	ctx := context.Background()
+	records, err := modelRecords(list)
+	if err != nil {
+		return importSection{}, errors.Wrapf(err, "%s", kind)
+	}
+
+	return importSection{
+		kind:    kind,
+		records: records,
+		save: func(record importRecord) error {
+			return store.Save(ctx, record.model)
+		},
+		delete: func(key string) error {
+			return store.Delete(ctx, key)
+		},
+	}, nil
+}
+
+// importSections validates the document and splits it by bucket, before anything is written
+func (m *Manager) importSections(doc ExportDocument) ([]importSection, error) {
+	if doc.Version <= 0 || doc.Version > ExportVersion {
+		return nil, errors.Wrapf(ErrInvalid, "unsupported export version %d", doc.Version)
+	}
+	if doc.SchemaVersion > database.SchemaVersion() {
+		return nil, errors.Wrapf(ErrInvalid, "export schema version %d is newer than the supported version %d", doc.SchemaVersion, database.SchemaVersion())
+	}
+
+	var movies, profiles, blocklist, pending, history []database.Model
+	for i := range doc.Movies {
+		movies = append(movies, &doc.Movies[i])
+	}
+	for i := range doc.Profiles {
+		profiles = append(profiles, &doc.Profiles[i])
+	}
+	for i := range doc.Blocklist {
+		blocklist = append(blocklist, &doc.Blocklist[i])
+	}
+	for i := range doc.PendingImports {
+		pending = append(pending, &doc.PendingImports[i])
+	}
+	for i := range doc.History {
+		history = append(history, &doc.History[i])
+	}
+
+	var sections []importSection
+	for _, s := range []struct {
+		kind  string
+		store database.Store
+		list  []database.Model
+	}{
+		{models.MovieKind, m.Movies, movies},
+		{models.ProfileKind, m.Profiles, profiles},
+		{models.BlocklistKind, m.Blocklisted, blocklist},
+		{models.PendingImportKind, m.Pending, pending},
+		{models.HistoryKind, m.History, history},
+	} {
+		section, err := storeSection(s.kind, s.store, s.list)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section)
+	}
+
+	settings := importSection{
+		kind:    models.SettingsKind,
+		private: privateSettings,
+		save: func(record importRecord) error {
+			return m.DB.Store(models.SettingsKind, []byte(record.key), record.data)
+		},
+		delete: func(key string) error {
+			return m.DB.Delete(models.SettingsKind, []byte(key))
+		},
+	}
+	var keys []string
+	for key := range doc.Settings {
+		if key == "" {
+			return nil, errors.Wrap(ErrInvalid, "setting without a key")
+		}
+		if privateSettings[key] {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		settings.records = append(settings.records, importRecord{key: key, data: []byte(doc.Settings[key])})
+	}
+	sections = append(sections, settings)
+
+	return sections, nil
+}
+
+// importSection writes the records of a section, unless dryRun is set, and reports the changes
+func (m *Manager) importSection(section importSection, mode ImportMode, dryRun bool) (*ImportChanges, error) {
+	changes := &ImportChanges{}
+
+	keys, err := m.DB.BucketKeys(section.kind)
+	if err != nil {
+		return nil, errors.Wrap(err, "m.DB.BucketKeys")
+	}
+	imported := make(map[string]bool)
+	for _, record := range section.records {
+		imported[record.key] = true
+
+		current, err := m.DB.Read([]byte(section.kind), []byte(record.key))
+		if err != nil {
+			return nil, errors.Wrap(err, "m.DB.Read")
+		}
+		switch {
+		case current == nil:
+			changes.Created = append(changes.Created, record.key)
+		case bytes.Equal(current, record.data):
+			changes.Unchanged++
+			continue
+		default:
+			changes.Updated = append(changes.Updated, record.key)
+		}
+
+		if dryRun {
+			continue
+		}
+		if err := section.save(record); err != nil {
+			return nil, errors.Wrapf(err, "save %s", record.key)
+		}
+	}
+
+	if mode != ImportReplace {
+		return changes, nil
+	}
+	for _, key := range keys {
+		if imported[string(key)] || section.private[string(key)] {
+			continue
+		}
+		changes.Deleted = append(changes.Deleted, string(key))
+
+		if dryRun {
+			continue
+		}
+		if err := section.delete(string(key)); err != nil {
+			return nil, errors.Wrapf(err, "delete %s", key)
+		}
+	}
+
+	return changes, nil
+}
+
+// Import writes the records of an export document. Records are upserted; in replace mode, the records
+// missing from the document are deleted too. The document is validated before anything is written, but
+// the import is not atomic: take a backup before replacing a library.
+func (m *Manager) Import(doc ExportDocument, options ImportOptions) (ImportReport, error) {
+	if options.Mode == "" {
+		options.Mode = ImportMerge
+	}
+	report := ImportReport{Mode: options.Mode, DryRun: options.DryRun, Changes: make(map[string]*ImportChanges)}
+
+	if options.Mode != ImportMerge && options.Mode != ImportReplace {
+		return report, errors.Wrapf(ErrInvalid, "unknown import mode %q", options.Mode)
+	}
+
+	sections, err := m.importSections(doc)
+	if err != nil {
+		return report, errors.Wrap(err, "m.importSections")
+	}
+
+	for _, section := range sections {
+		changes, err := m.importSection(section, options.Mode, options.DryRun)
+		if err != nil {
+			return report, errors.Wrapf(err, "m.importSection (%s)", section.kind)
+		}
+		report.Changes[section.kind] = changes
+	}
+
+	if options.DryRun {
+		return report, nil
+	}
+
+	// a replace may have removed the default profile or the api key
+	if err := m.ensureDefaultProfile(); err != nil {
+		return report, errors.Wrap(err, "m.ensureDefaultProfile")
+	}
+	if err := m.ensureApiKey(); err != nil {
+		return report, errors.Wrap(err, "m.ensureApiKey")
+	}
+
+	return report, nil
+}
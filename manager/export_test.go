@@ -0,0 +1,154 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"pomegranate/database"
+	"pomegranate/models"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestManager_ExportImport(t *testing.T) {
+	src, cleanupSrc := newTestManager(t)
+	defer cleanupSrc()
+	dst, cleanupDst := newTestManager(t)
+	defer cleanupDst()
+
+	ctx := context.Background()
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix", NzbInfo: []models.NzbInfo{{ID: "1", GUID: "guid-1"}}}
+	if err := src.Movies.Save(ctx, &movie); err != nil {
+		t.Fatalf("src.Movies.Save: %s", err)
+	}
+	extra := models.Movie{ImdbId: "tt0000001", Title: "Only in the destination"}
+	if err := dst.Movies.Save(ctx, &extra); err != nil {
+		t.Fatalf("dst.Movies.Save: %s", err)
+	}
+
+	doc, err := src.Export()
+	if err != nil {
+		t.Fatalf("src.Export: %s", err)
+	}
+	if len(doc.Movies) != 1 || len(doc.Profiles) != 1 {
+		t.Fatalf("unexpected export: %+v", doc)
+	}
+	if _, ok := doc.Settings[models.ApiKeySetting]; ok {
+		t.Errorf("the api key should not be exported")
+	}
+
+	report, err := dst.Import(doc, ImportOptions{Mode: ImportReplace, DryRun: true})
+	if err != nil {
+		t.Fatalf("dst.Import (dry run): %s", err)
+	}
+	movies := report.Changes[models.MovieKind]
+	if !reflect.DeepEqual(movies.Created, []string{"tt0133093"}) || !reflect.DeepEqual(movies.Deleted, []string{"tt0000001"}) {
+		t.Errorf("unexpected movie changes: %+v", movies)
+	}
+	if settings := report.Changes[models.SettingsKind]; len(settings.Updated) != 0 || len(settings.Deleted) != 0 {
+		t.Errorf("unexpected settings changes: %+v", settings)
+	}
+	if _, err := dst.MovieWithNzbGUID("guid-1"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("a dry run should not write anything, got %v", err)
+	}
+
+	if _, err := dst.Import(doc, ImportOptions{}); err != nil {
+		t.Fatalf("dst.Import (merge): %s", err)
+	}
+	if _, err := dst.Movie(extra.ImdbId); err != nil {
+		t.Errorf("a merge should keep existing movies: %s", err)
+	}
+	// indexes are maintained by the store
+	if found, err := dst.MovieWithNzbGUID("guid-1"); err != nil || found.ImdbId != movie.ImdbId {
+		t.Errorf("dst.MovieWithNzbGUID: %+v, %v", found, err)
+	}
+
+	report, err = dst.Import(doc, ImportOptions{Mode: ImportReplace})
+	if err != nil {
+		t.Fatalf("dst.Import (replace): %s", err)
+	}
+	if movies := report.Changes[models.MovieKind]; movies.Unchanged != 1 || len(movies.Deleted) != 1 {
+		t.Errorf("unexpected movie changes: %+v", movies)
+	}
+	if _, err := dst.Movie(extra.ImdbId); err == nil {
+		t.Errorf("a replace should delete movies missing from the document")
+	}
+
+	doc.Version = ExportVersion + 1
+	if _, err := dst.Import(doc, ImportOptions{}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a newer document, got %v", err)
+	}
+	if _, err := dst.Import(ExportDocument{Version: 1}, ImportOptions{Mode: "overwrite"}); !errors.Is(err, ErrInvalid) {
+		t.Errorf("expected ErrInvalid for an unknown mode, got %v", err)
+	}
+}
+
+func TestManager_ImportReplaceLarge(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	var stale []string
+	for i := 0; i < 200; i++ {
+		movie := models.Movie{ImdbId: fmt.Sprintf("tt1%06d", i), Title: "Stale"}
+		if err := m.Movies.Save(ctx, &movie); err != nil {
+			t.Fatalf("m.Movies.Save: %s", err)
+		}
+		stale = append(stale, movie.ImdbId)
+	}
+
+	// megabytes of new records grow the file, remapping it while the import runs
+	overview := strings.Repeat("x", 8192)
+	doc := ExportDocument{Version: ExportVersion, Settings: map[string]string{}}
+	for i := 0; i < 500; i++ {
+		doc.Movies = append(doc.Movies, models.Movie{ImdbId: fmt.Sprintf("tt2%06d", i), Title: "Imported", Overview: overview})
+	}
+
+	report, err := m.Import(doc, ImportOptions{Mode: ImportReplace})
+	if err != nil {
+		t.Fatalf("m.Import: %s", err)
+	}
+	movies := report.Changes[models.MovieKind]
+	if len(movies.Created) != 500 || !reflect.DeepEqual(movies.Deleted, stale) {
+		t.Errorf("unexpected movie changes: %d created, deleted %v", len(movies.Created), movies.Deleted)
+	}
+
+	count, err := m.Movies.Count(ctx)
+	if err != nil {
+		t.Fatalf("m.Movies.Count: %s", err)
+	}
+	if count != 500 {
+		t.Errorf("expected 500 movies after the replace, got %d", count)
+	}
+}
+
+func TestManager_ImportKeepsApiKey(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	key, err := m.ApiKey()
+	if err != nil {
+		t.Fatalf("m.ApiKey: %s", err)
+	}
+
+	doc := ExportDocument{Version: ExportVersion, Settings: map[string]string{models.ApiKeySetting: "foreign-key"}}
+	for _, mode := range []ImportMode{ImportMerge, ImportReplace} {
+		report, err := m.Import(doc, ImportOptions{Mode: mode})
+		if err != nil {
+			t.Fatalf("m.Import (%s): %s", mode, err)
+		}
+		if settings := report.Changes[models.SettingsKind]; len(settings.Created)+len(settings.Updated)+len(settings.Deleted) != 0 {
+			t.Errorf("%s: the api key should be left alone: %+v", mode, settings)
+		}
+
+		current, err := m.ApiKey()
+		if err != nil {
+			t.Fatalf("m.ApiKey: %s", err)
+		}
+		if current != key {
+			t.Errorf("%s: the api key was replaced by the import", mode)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+	"log"
+
+	"pomegranate/database"
+	"pomegranate/metrics"
+	"pomegranate/models"
+
+	"github.com/pkg/errors"
+)
+
+// Grab sends the given release of a movie to the downloader and records the updated release status.
+// movie is replaced by the stored movie with the update applied.
+func (m *Manager) Grab(movie *models.Movie, nzbID string) (*models.NzbInfo, error) {
+	return m.grab(movie, nzbID, models.ActorUser)
+}
+
+func (m *Manager) grab(movie *models.Movie, nzbID string, actor string) (*models.NzbInfo, error) {
+	if m.Downloader == nil {
+		return nil, errors.New("no downloader is configured")
+	}
+
+	nzb := movie.Release(nzbID)
+	if nzb == nil {
+		return nil, errors.Wrapf(database.ErrNotFound, "nzb %s", nzbID)
+	}
+
+	if err := m.checkGrabQuota(*nzb); err != nil {
+		return nil, errors.Wrap(err, "m.checkGrabQuota")
+	}
+
+	id, err := m.Downloader.AddByURL(nzb.URL, nzb.Title)
+	if err != nil {
+		return nil, errors.Wrap(upstream(m.Downloader.Name(), err), "AddByURL")
+	}
+	if n, ok := m.releaseIndexer(*nzb); ok {
+		m.recordGrab(n.Host)
+	}
+
+	// the release is updated on the stored movie, which may have changed while the downloader was called
+	var before models.NzbStatus
+	err = m.Movies.Update(context.Background(), movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		storedNzb := stored.Release(nzbID)
+		if storedNzb == nil {
+			return errors.Wrapf(database.ErrNotFound, "nzb %s", nzbID)
+		}
+
+		before = storedNzb.Status
+		storedNzb.DownloaderId = id
+		storedNzb.Status = models.StatusSnatched
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "m.Movies.Update")
+	}
+	metrics.Grabs.Inc()
+	m.recordHistory(models.EventReleaseGrabbed, actor, *movie, nzbID, string(before), models.StatusSnatched)
+
+	return movie.Release(nzbID), nil
+}
+
+// GrabNzb grabs a release given only its id
+func (m *Manager) GrabNzb(nzbID string) (models.Movie, error) {
+	movie, err := m.MovieWithNzbID(nzbID)
+	if err != nil {
+		return movie, errors.Wrapf(err, "m.MovieWithNzbID (%s)", nzbID)
+	}
+
+	if _, err := m.Grab(&movie, nzbID); err != nil {
+		return movie, errors.Wrap(err, "m.Grab")
+	}
+
+	return movie, nil
+}
+
+// GrabBest sends the best release of a movie, according to its quality profile, to the downloader.
+// Releases on indexers that reached their grab limit are passed over.
+// Nothing is done if the movie already has a release snatched or downloaded, if no release matches
+// the profile or if no downloader is configured. A nil release is returned when nothing was grabbed.
+func (m *Manager) GrabBest(movie *models.Movie) (*models.NzbInfo, error) {
+	return m.grabBest(movie, models.ActorUser)
+}
+
+func (m *Manager) grabBest(movie *models.Movie, actor string) (*models.NzbInfo, error) {
+	if m.Downloader == nil || movie.Grabbed() {
+		return nil, nil
+	}
+
+	evaluations, err := m.MovieReleases(*movie)
+	if err != nil {
+		return nil, errors.Wrap(err, "m.MovieReleases")
+	}
+
+	// the best release on an indexer with grabs left
+	for _, evaluation := range evaluations {
+		if !evaluation.Accepted {
+			break
+		}
+		nzb := movie.Release(evaluation.NzbID)
+		if nzb == nil {
+			continue
+		}
+		if err := m.checkGrabQuota(*nzb); err != nil {
+			if errors.Is(err, ErrQuotaExhausted) {
+				log.Printf("skipping %s for %s: %s\n", nzb.Title, movie.ImdbId, err)
+				continue
+			}
+			return nil, errors.Wrap(err, "m.checkGrabQuota")
+		}
+
+		nzb, err := m.grab(movie, evaluation.NzbID, actor)
+		if err != nil {
+			return nil, errors.Wrap(err, "m.grab")
+		}
+		log.Printf("grabbed %s for %s (score %d)\n", nzb.Title, movie.ImdbId, evaluation.Score)
+
+		return nzb, nil
+	}
+
+	return nil, nil
+}
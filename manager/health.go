@@ -0,0 +1,169 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/models"
+	"pomegranate/newznab"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// indexerBackoffBase is the time an indexer is left alone after its first failure. It doubles with
+	// every consecutive failure, up to indexerBackoffMax.
+	indexerBackoffBase = time.Minute
+	indexerBackoffMax  = 12 * time.Hour
+	// indexerLimitBackoff is the minimum time an indexer is left alone once over its limits, when they
+	// are unknown. Otherwise it is left alone until they reset.
+	indexerLimitBackoff = time.Hour
+)
+
+// Indexer health states reported by IndexerHealth
+const (
+	IndexerHealthy = "healthy"
+	IndexerFailing = "failing" // Failed recently, but may be queried
+	IndexerBackoff = "backoff" // Left alone until NextRetryAt
+)
+
+// IndexerHealth is the status of a configured indexer
+type IndexerHealth struct {
+	models.IndexerStatus
+	State string       `json:"state"`
+	Quota IndexerQuota `json:"quota"`
+}
+
+// indexerBackoff returns how long an indexer is left alone after the given number of consecutive failures
+func indexerBackoff(failures int, err error) time.Duration {
+	backoff := indexerBackoffMax
+	if failures <= 20 {
+		backoff = indexerBackoffBase << uint(failures-1)
+	}
+	if backoff > indexerBackoffMax {
+		backoff = indexerBackoffMax
+	}
+
+	var newznabErr newznab.Error
+	if errors.As(err, &newznabErr) {
+		switch {
+		case newznabErr.AccountError():
+			backoff = indexerBackoffMax
+		case newznabErr.LimitReached() && backoff < indexerLimitBackoff:
+			backoff = indexerLimitBackoff
+		}
+	}
+
+	return backoff
+}
+
+// indexerStatus reads the status of an indexer, which is empty for indexers that never failed
+func (m *Manager) indexerStatus(ctx context.Context, host string) (models.IndexerStatus, error) {
+	status := models.IndexerStatus{Host: host}
+	err := m.IndexerStatuses.FindByID(ctx, &status, host)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return status, errors.Wrap(err, "m.IndexerStatuses.FindByID")
+	}
+
+	return status, nil
+}
+
+// recordIndexerResult updates the status of an indexer after a search: a success resets its failures,
+// a failure puts it into backoff
+func (m *Manager) recordIndexerResult(ctx context.Context, n newznab.Newznab, searchErr error) error {
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	status, err := m.indexerStatus(ctx, n.Host)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if searchErr == nil {
+		status.Failures = 0
+		status.LastSuccessAt = now
+		status.NextRetryAt = time.Time{}
+	} else {
+		status.Failures++
+		status.LastError = searchErr.Error()
+		status.ErrorCode = 0
+		status.LastFailureAt = now
+		status.NextRetryAt = now.Add(indexerBackoff(status.Failures, searchErr))
+		var newznabErr newznab.Error
+		if errors.As(searchErr, &newznabErr) {
+			status.ErrorCode = newznabErr.Code
+			if newznabErr.LimitReached() {
+				quota, err := m.indexerQuota(ctx, n)
+				if err != nil {
+					return errors.Wrap(err, "m.indexerQuota")
+				}
+				if quota.ApiLimit > 0 || quota.GrabLimit > 0 {
+					status.NextRetryAt = quota.ResetsAt
+				}
+			}
+		}
+		log.Printf("indexer %s failed %d times in a row, next retry at %s\n", n.Host, status.Failures, status.NextRetryAt.Format(time.RFC3339))
+	}
+
+	if err := m.IndexerStatuses.Save(ctx, &status); err != nil {
+		return errors.Wrap(err, "m.IndexerStatuses.Save")
+	}
+
+	return nil
+}
+
+// IndexerHealth returns the status and the daily usage of every configured indexer
+func (m *Manager) IndexerHealth() ([]IndexerHealth, error) {
+	ctx := context.Background()
+	now := time.Now()
+
+	health := []IndexerHealth{}
+	for _, n := range m.Indexers {
+		status, err := m.indexerStatus(ctx, n.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		state := IndexerHealthy
+		switch {
+		case !status.Available(now):
+			state = IndexerBackoff
+		case status.Failures > 0:
+			state = IndexerFailing
+		}
+		quota, err := m.indexerQuota(ctx, n)
+		if err != nil {
+			return nil, errors.Wrap(err, "m.indexerQuota")
+		}
+		health = append(health, IndexerHealth{IndexerStatus: status, State: state, Quota: quota})
+	}
+
+	return health, nil
+}
+
+// ResetIndexer clears the failures of a configured indexer, so it is queried again right away
+func (m *Manager) ResetIndexer(host string) error {
+	configured := false
+	for _, n := range m.Indexers {
+		if n.Host == host {
+			configured = true
+			break
+		}
+	}
+	if !configured {
+		return errors.Wrapf(database.ErrNotFound, "indexer %s", host)
+	}
+
+	m.healthMu.Lock()
+	defer m.healthMu.Unlock()
+
+	err := m.IndexerStatuses.Delete(context.Background(), host)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return errors.Wrap(err, "m.IndexerStatuses.Delete")
+	}
+
+	return nil
+}
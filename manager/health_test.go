@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManager_IndexerBackoff(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "movie" {
+			http.Error(w, "unsupported", http.StatusNotImplemented)
+			return
+		}
+		requests++
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="500" description="Request limit reached"/>`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	m.Indexers = []newznab.Newznab{{Host: host}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	if _, err := m.SearchReleases(context.Background(), &movie); err == nil {
+		t.Fatalf("expected the search to fail")
+	}
+
+	health, err := m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if len(health) != 1 || health[0].State != IndexerBackoff || health[0].Failures != 1 || health[0].ErrorCode != newznab.CodeRequestLimitReached {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	if wait := time.Until(health[0].NextRetryAt); wait < 59*time.Minute {
+		t.Errorf("an indexer over its limit should be left alone for an hour, got %s", wait)
+	}
+
+	// the indexer is skipped while in backoff, which is not a failed search
+	if _, err := m.SearchReleases(context.Background(), &movie); err != nil || requests != 1 {
+		t.Errorf("indexer in backoff should be skipped: %d requests, err %v", requests, err)
+	}
+	if result := movie.LastSearch.Indexers[0]; !result.Skipped || !strings.Contains(result.Error, "backing off") {
+		t.Errorf("unexpected result in search report: %+v", result)
+	}
+	if movie.LastSearch.Failed() {
+		t.Errorf("a search skipping every indexer should not be failed: %+v", movie.LastSearch)
+	}
+
+	// nor does it fail adding a movie
+	finder := fakeFinder{imdbIds: map[int32]string{949: "tt0113277"}}
+	added, err := m.SaveMovie(finder, "949", "", false)
+	if err != nil || requests != 1 {
+		t.Fatalf("adding a movie with the indexer in backoff: %d requests, err %v", requests, err)
+	}
+	if added.LastSearch == nil || added.LastSearch.Failed() || !added.LastSearch.Indexers[0].Skipped {
+		t.Errorf("unexpected search report of the added movie: %+v", added.LastSearch)
+	}
+
+	if err := m.ResetIndexer(host); err != nil {
+		t.Fatalf("m.ResetIndexer: %s", err)
+	}
+	health, err = m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if health[0].State != IndexerHealthy {
+		t.Errorf("reset indexer should be healthy: %+v", health[0])
+	}
+	// with a known limit, the indexer is left alone until the limit resets
+	m.Indexers[0].ApiLimit = 100
+	if _, err := m.SearchReleases(context.Background(), &movie); err == nil || requests != 2 {
+		t.Errorf("reset indexer should be queried again: %d requests, err %v", requests, err)
+	}
+	health, err = m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if !health[0].NextRetryAt.Equal(health[0].Quota.ResetsAt) {
+		t.Errorf("expected the backoff to end at %s, got %s", health[0].Quota.ResetsAt, health[0].NextRetryAt)
+	}
+}
+
+func TestManager_IndexerLastErrorHidesApiKey(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	// a closed server refuses the connection, an error quoting the request url
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(server.URL, "http://"), ApiKey: "SECRETKEY"}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	if _, err := m.SearchReleases(context.Background(), &movie); err == nil {
+		t.Fatalf("expected the search to fail")
+	}
+
+	health, err := m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if lastError := health[0].LastError; lastError == "" || strings.Contains(lastError, "SECRETKEY") {
+		t.Errorf("unexpected last error: %s", lastError)
+	}
+}
+
+func TestIndexerBackoff(t *testing.T) {
+	testCases := []struct {
+		failures int
+		err      error
+		expected time.Duration
+	}{
+		{failures: 1, expected: time.Minute},
+		{failures: 3, expected: 4 * time.Minute},
+		{failures: 11, expected: indexerBackoffMax},
+		{failures: 100, expected: indexerBackoffMax},
+		{failures: 1, err: newznab.Error{Code: newznab.CodeTooManyRequests}, expected: time.Hour},
+		{failures: 10, err: newznab.Error{Code: newznab.CodeDownloadLimitReached}, expected: 512 * time.Minute},
+		{failures: 1, err: upstream("indexer", newznab.Error{Code: newznab.CodeIncorrectCredentials}), expected: indexerBackoffMax},
+	}
+
+	for _, tc := range testCases {
+		if backoff := indexerBackoff(tc.failures, tc.err); backoff != tc.expected {
+			t.Errorf("%d failures, %v: expected %s, got %s", tc.failures, tc.err, tc.expected, backoff)
+		}
+	}
+}
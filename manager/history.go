@@ -0,0 +1,76 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/models"
+
+	"github.com/lsmoura/humantoken"
+	"github.com/pkg/errors"
+)
+
+// DefaultHistoryLimit is the page size of history queries without a limit
+const DefaultHistoryLimit = 50
+
+// historyID builds an id sorting chronologically, with a random suffix for events of the same instant
+func historyID(t time.Time) string {
+	return fmt.Sprintf("%020d-%s", t.UnixNano(), humantoken.Generate(4, nil))
+}
+
+// recordHistory appends an event of a movie to the history, and publishes it on the event bus. A failure
+// is only logged: the change the event describes already happened.
+func (m *Manager) recordHistory(event models.HistoryEvent, actor string, movie models.Movie, nzbID string, before string, after string) {
+	now := time.Now().UTC()
+	entry := models.HistoryEntry{
+		ID:        historyID(now),
+		Event:     event,
+		ImdbId:    movie.ImdbId,
+		Title:     movie.Title,
+		NzbID:     nzbID,
+		Actor:     actor,
+		Before:    before,
+		After:     after,
+		CreatedAt: now,
+	}
+
+	if err := m.History.Save(context.Background(), &entry); err != nil {
+		log.Printf("cannot record %s event of %s: %s\n", event, movie.ImdbId, err)
+	}
+	m.publish(EventType(event), movie, nzbID, after)
+}
+
+// releasesSummary describes the releases of a movie for the history
+func releasesSummary(count int) string {
+	if count == 1 {
+		return "1 release"
+	}
+
+	return fmt.Sprintf("%d releases", count)
+}
+
+// ListHistory returns a page of history entries, newest first. Entries are limited to a single movie
+// when imdbId is not empty. Entry ids sort chronologically, so pages are read in key order, or in the
+// order of the imdb id index, without decoding the whole history.
+func (m *Manager) ListHistory(imdbId string, limit int, cursor string) ([]models.HistoryEntry, database.PageInfo, error) {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+
+	r := database.Range{Descending: true, Limit: limit, Cursor: cursor}
+	if imdbId != "" {
+		r.Index = models.HistoryImdbIndex
+		r.Prefix = models.HistoryImdbPrefix(imdbId)
+	}
+
+	var entries []models.HistoryEntry
+	info, err := m.History.FindRange(context.Background(), &entries, r)
+	if err != nil {
+		return nil, info, errors.Wrap(err, "m.History.FindRange")
+	}
+
+	return entries, info, nil
+}
@@ -0,0 +1,71 @@
+package manager
+
+import (
+	"pomegranate/models"
+	"pomegranate/themoviedb"
+	"testing"
+)
+
+func TestManager_History(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	finder := fakeFinder{
+		searches: map[string][]themoviedb.Entry{},
+		imdbIds:  map[int32]string{603: "tt0133093", 604: "tt0234215"},
+	}
+	for _, identifier := range []string{"603", "604"} {
+		if _, err := m.SaveMovie(finder, identifier, "", false); err != nil {
+			t.Fatalf("m.SaveMovie: %s", err)
+		}
+	}
+	if err := m.DeleteMovie("tt0133093"); err != nil {
+		t.Fatalf("m.DeleteMovie: %s", err)
+	}
+
+	entries, info, err := m.ListHistory("", 2, "")
+	if err != nil {
+		t.Fatalf("m.ListHistory: %s", err)
+	}
+	if info.Total != 3 || info.NextCursor == "" || len(entries) != 2 {
+		t.Fatalf("unexpected first page: %+v, %+v", entries, info)
+	}
+	// newest first
+	if entries[0].Event != models.EventMovieDeleted || entries[0].ImdbId != "tt0133093" || entries[0].Actor != models.ActorUser {
+		t.Errorf("unexpected latest entry: %+v", entries[0])
+	}
+	if entries[1].Event != models.EventMovieAdded || entries[1].ImdbId != "tt0234215" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+
+	entries, info, err = m.ListHistory("", 2, info.NextCursor)
+	if err != nil {
+		t.Fatalf("m.ListHistory: %s", err)
+	}
+	if len(entries) != 1 || info.NextCursor != "" || entries[0].ImdbId != "tt0133093" {
+		t.Errorf("unexpected last page: %+v, %+v", entries, info)
+	}
+
+	entries, info, err = m.ListHistory("tt0133093", 0, "")
+	if err != nil {
+		t.Fatalf("m.ListHistory: %s", err)
+	}
+	if info.Total != 2 || len(entries) != 2 || entries[1].Event != models.EventMovieAdded || entries[1].After != "Movie 603" {
+		t.Errorf("unexpected movie history: %+v", entries)
+	}
+
+	entries, info, err = m.ListHistory("tt0133093", 1, "")
+	if err != nil {
+		t.Fatalf("m.ListHistory: %s", err)
+	}
+	if info.Total != 2 || len(entries) != 1 || entries[0].Event != models.EventMovieDeleted || info.NextCursor == "" {
+		t.Fatalf("unexpected first page of the movie history: %+v, %+v", entries, info)
+	}
+	entries, info, err = m.ListHistory("tt0133093", 1, info.NextCursor)
+	if err != nil {
+		t.Fatalf("m.ListHistory: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Event != models.EventMovieAdded || info.NextCursor != "" {
+		t.Errorf("unexpected last page of the movie history: %+v, %+v", entries, info)
+	}
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/importer"
+	"pomegranate/models"
+
+	"github.com/pkg/errors"
+)
+
+// ImportDownload places the file of a completed release into the library and records it on the movie.
+// A failed import is recorded on the release, and retried by MonitorDownloads.
+func (m *Manager) ImportDownload(movie *models.Movie, nzbID string) error {
+	if m.Importer == nil {
+		return errors.New("no library is configured")
+	}
+
+	nzb := movie.Release(nzbID)
+	if nzb == nil {
+		return errors.Wrapf(database.ErrNotFound, "nzb %s", nzbID)
+	}
+	if nzb.Status != models.StatusSuccess || nzb.StoragePath == "" {
+		return errors.Errorf("nzb %s has no completed download", nzbID)
+	}
+
+	info := nzb.ReleaseInfo()
+	naming := importer.Naming{
+		Title:   movie.Title,
+		Year:    movie.Year(),
+		Quality: info.Quality(),
+		ImdbId:  movie.ImdbId,
+		Edition: info.Edition,
+		Group:   info.Group,
+	}
+
+	result, err := m.Importer.Import(nzb.StoragePath, naming)
+	if err != nil {
+		if recordErr := m.recordImportError(movie, nzbID, err.Error()); recordErr != nil {
+			log.Printf("cannot record the import error of %s: %s\n", nzbID, recordErr)
+		}
+		return errors.Wrap(err, "m.Importer.Import")
+	}
+
+	before := ""
+	if movie.File != nil {
+		before = movie.File.Path
+	}
+	file := &models.MovieFile{
+		Path:       result.Path,
+		Size:       result.Size,
+		Quality:    naming.Quality,
+		NzbID:      nzb.ID,
+		ImportedAt: time.Now().UTC(),
+	}
+	err = m.Movies.Update(context.Background(), movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		stored.File = file
+		if imported := stored.Release(nzbID); imported != nil {
+			imported.ImportError = ""
+		}
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "m.Movies.Update")
+	}
+	log.Printf("imported %s for %s to %s\n", nzb.Title, movie.ImdbId, result.Path)
+	m.recordHistory(models.EventMovieImported, models.ActorSystem, *movie, nzbID, before, result.Path)
+
+	return nil
+}
+
+// recordImportError records why a completed release could not be imported
+func (m *Manager) recordImportError(movie *models.Movie, nzbID string, message string) error {
+	err := m.Movies.Update(context.Background(), movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		nzb := stored.Release(nzbID)
+		if nzb == nil {
+			return errUnchanged
+		}
+		nzb.ImportError = message
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil && err != errUnchanged {
+		return errors.Wrap(err, "m.Movies.Update")
+	}
+
+	return nil
+}
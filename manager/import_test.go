@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pomegranate/importer"
+	"pomegranate/models"
+	"pomegranate/sabnzbd"
+	"strings"
+	"testing"
+)
+
+func TestManager_MonitorDownloadsImports(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	download := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(download, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv"), []byte("movie"), 0664); err != nil {
+		t.Fatalf("ioutil.WriteFile: %s", err)
+	}
+
+	library, err := importer.New(t.TempDir(), importer.ModeCopy, "")
+	if err != nil {
+		t.Fatalf("importer.New: %s", err)
+	}
+	m.Importer = &library
+
+	server := fakeSabnzbd(t,
+		`{"queue": {"slots": []}}`,
+		`{"history": {"slots": [{"nzo_id": "nzo_completed", "status": "Completed", "storage": "`+download+`"}]}}`,
+	)
+	defer server.Close()
+	m.Downloader = sabnzbd.New(strings.TrimPrefix(server.URL, "http://"), "")
+
+	movie := models.Movie{
+		ImdbId:      "tt0133093",
+		Title:       "The Matrix",
+		ReleaseDate: "1999-03-30",
+		NzbInfo: []models.NzbInfo{
+			{ID: "completed", Title: "The.Matrix.1999.1080p.BluRay.x264-GRP", Status: models.StatusSnatched, DownloaderId: "nzo_completed"},
+		},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	if err := m.MonitorDownloads(context.Background()); err != nil {
+		t.Fatalf("m.MonitorDownloads: %s", err)
+	}
+
+	updated, err := m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if updated.File == nil {
+		t.Fatal("movie file was not recorded")
+	}
+
+	expected := filepath.Join(library.Root, "The Matrix (1999)", "The Matrix (1999) [1080p BluRay].mkv")
+	if updated.File.Path != expected {
+		t.Errorf("expected path %s, got %s", expected, updated.File.Path)
+	}
+	if updated.File.Size != 5 || updated.File.Quality != "1080p BluRay" || updated.File.NzbID != "completed" {
+		t.Errorf("unexpected movie file: %+v", updated.File)
+	}
+	if _, err := os.Stat(expected); err != nil {
+		t.Errorf("imported file is missing: %s", err)
+	}
+}
+
+func TestManager_MonitorDownloadsRetriesImports(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	// the download has no video yet, so the first import fails
+	download := t.TempDir()
+	library, err := importer.New(t.TempDir(), importer.ModeCopy, "")
+	if err != nil {
+		t.Fatalf("importer.New: %s", err)
+	}
+	m.Importer = &library
+
+	server := fakeSabnzbd(t,
+		`{"queue": {"slots": []}}`,
+		`{"history": {"slots": [{"nzo_id": "nzo_completed", "status": "Completed", "storage": "`+download+`"}]}}`,
+	)
+	defer server.Close()
+	m.Downloader = sabnzbd.New(strings.TrimPrefix(server.URL, "http://"), "")
+
+	movie := models.Movie{
+		ImdbId:      "tt0133093",
+		Title:       "The Matrix",
+		ReleaseDate: "1999-03-30",
+		NzbInfo: []models.NzbInfo{
+			{ID: "completed", Title: "The.Matrix.1999.1080p.BluRay.x264-GRP", Status: models.StatusSnatched, DownloaderId: "nzo_completed"},
+		},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	if err := m.MonitorDownloads(context.Background()); err != nil {
+		t.Fatalf("m.MonitorDownloads: %s", err)
+	}
+	updated, err := m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if nzb := updated.Release("completed"); nzb.Status != models.StatusSuccess || nzb.ImportError == "" {
+		t.Errorf("the failed import should be recorded: %+v", nzb)
+	}
+	if updated.File != nil || updated.Downloaded() {
+		t.Errorf("a movie whose import failed should not be downloaded: %+v", updated.File)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(download, "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv"), []byte("movie"), 0664); err != nil {
+		t.Fatalf("ioutil.WriteFile: %s", err)
+	}
+	if err := m.MonitorDownloads(context.Background()); err != nil {
+		t.Fatalf("m.MonitorDownloads: %s", err)
+	}
+	updated, err = m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if updated.File == nil || !updated.Downloaded() || updated.Release("completed").ImportError != "" {
+		t.Errorf("the import should be retried: %+v, %+v", updated.File, updated.Release("completed"))
+	}
+}
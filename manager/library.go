@@ -0,0 +1,304 @@
+package manager
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/importer"
+	"pomegranate/models"
+	"pomegranate/release"
+	"pomegranate/themoviedb"
+
+	"github.com/pkg/errors"
+)
+
+// maxCandidates limits how many themoviedb results are kept on a pending import
+const maxCandidates = 5
+
+// MovieFinder looks up movies on themoviedb. It is satisfied by themoviedb.Themoviedb.
+type MovieFinder interface {
+	ReadMovies(search string, page int) (themoviedb.Response, error)
+	ReadSingleMovie(key string) (themoviedb.SingleMovieResponse, error)
+}
+
+// ScanResult summarizes a library scan
+type ScanResult struct {
+	Scanned  int      `json:"scanned"`
+	Imported int      `json:"imported"`
+	Pending  int      `json:"pending"`
+	Skipped  int      `json:"skipped"` // Files already known, either as a movie file or a pending import
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// libraryFile is a movie file found on disk
+type libraryFile struct {
+	Path    string
+	Size    int64
+	Title   string
+	Year    int
+	Quality string
+}
+
+func pendingImportID(path string) string {
+	sum := sha1.Sum([]byte(path))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// parseLibraryFile reads the title and year of a movie from its folder name, falling back to the file
+// name when the folder carries no year, as in "Movies/The Matrix (1999)/matrix.mkv"
+func parseLibraryFile(path string, size int64, folder string) libraryFile {
+	file := release.Parse(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	info := file
+	if folder != "" {
+		if parsed := release.Parse(folder); parsed.Year > 0 || file.Year == 0 {
+			info = parsed
+		}
+	}
+
+	quality := file.Quality()
+	if quality == "" {
+		quality = info.Quality()
+	}
+
+	return libraryFile{Path: path, Size: size, Title: info.Title, Year: info.Year, Quality: quality}
+}
+
+// libraryFiles lists the movies found in root. Every folder directly inside root is a movie, represented
+// by its main video file; video files placed directly inside root are movies as well.
+func libraryFiles(root string) ([]libraryFile, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadDir")
+	}
+
+	var files []libraryFile
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+
+		if !entry.IsDir() {
+			if importer.IsVideo(path) {
+				files = append(files, parseLibraryFile(path, entry.Size(), ""))
+			}
+			continue
+		}
+
+		video, err := importer.FindVideo(path)
+		if err != nil {
+			// folders without videos are not movies
+			continue
+		}
+		info, err := os.Stat(video)
+		if err != nil {
+			return nil, errors.Wrap(err, "os.Stat")
+		}
+		files = append(files, parseLibraryFile(video, info.Size(), entry.Name()))
+	}
+
+	return files, nil
+}
+
+// matchCandidates searches themoviedb for a file. It returns the results closest to the parsed title and
+// year, and whether the first of them is an unambiguous match.
+func matchCandidates(finder MovieFinder, file libraryFile) ([]models.MatchCandidate, bool, error) {
+	res, err := finder.ReadMovies(file.Title, 0)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "finder.ReadMovies")
+	}
+
+	var exact, others []models.MatchCandidate
+	title := normalizeTitle(file.Title)
+	for _, entry := range res.Results {
+		candidate := models.MatchCandidate{TmdbId: entry.Id, Title: entry.Title, ReleaseDate: entry.ReleaseDate}
+
+		sameTitle := normalizeTitle(entry.Title) == title || normalizeTitle(entry.OriginalTitle) == title
+		sameYear := file.Year == 0 || (models.Movie{ReleaseDate: entry.ReleaseDate}).Year() == file.Year
+		if sameTitle && sameYear {
+			exact = append(exact, candidate)
+		} else {
+			others = append(others, candidate)
+		}
+	}
+
+	// without a year, a single title match is not enough to rule out remakes
+	unique := len(exact) == 1 && file.Year != 0
+
+	candidates := append(exact, others...)
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	return candidates, unique, nil
+}
+
+// addLibraryMovie creates, or updates, the movie record of a themoviedb movie with a file from the library
+func (m *Manager) addLibraryMovie(finder MovieFinder, tmdbID int32, file libraryFile) (models.Movie, error) {
+	details, err := finder.ReadSingleMovie(fmt.Sprintf("%d", tmdbID))
+	if err != nil {
+		return models.Movie{}, errors.Wrapf(err, "finder.ReadSingleMovie (%d)", tmdbID)
+	}
+	if details.ImdbId == "" {
+		return models.Movie{}, errors.Errorf("movie %d has no imdb id", tmdbID)
+	}
+
+	movie, err := m.Movie(details.ImdbId)
+	created := errors.Is(err, database.ErrNotFound)
+	if err != nil && !created {
+		return models.Movie{}, errors.Wrap(err, "m.Movie")
+	}
+
+	before := ""
+	if movie.File != nil {
+		before = movie.File.Path
+	}
+	applyDetails(&movie, details)
+	movie.File = &models.MovieFile{
+		Path:       file.Path,
+		Size:       file.Size,
+		Quality:    file.Quality,
+		ImportedAt: time.Now().UTC(),
+	}
+
+	if err := m.Movies.Save(context.Background(), &movie); err != nil {
+		return models.Movie{}, errors.Wrap(err, "m.Movies.Save")
+	}
+	if created {
+		m.recordHistory(models.EventMovieAdded, models.ActorUser, movie, "", "", movie.Title)
+	}
+	m.recordHistory(models.EventMovieImported, models.ActorUser, movie, "", before, file.Path)
+
+	return movie, nil
+}
+
+// knownPaths lists the files already recorded on movies or waiting for confirmation
+func (m *Manager) knownPaths() (map[string]bool, error) {
+	movies, err := m.AllMovies()
+	if err != nil {
+		return nil, errors.Wrap(err, "m.AllMovies")
+	}
+	pending, err := m.PendingImports()
+	if err != nil {
+		return nil, errors.Wrap(err, "m.PendingImports")
+	}
+
+	known := make(map[string]bool)
+	for _, movie := range movies {
+		if movie.File != nil {
+			known[movie.File.Path] = true
+		}
+	}
+	for _, p := range pending {
+		known[p.Path] = true
+	}
+
+	return known, nil
+}
+
+// ScanLibrary walks root looking for movies not yet in the database. Files matching a single themoviedb
+// movie are added as downloaded movies; ambiguous ones are stored as pending imports, to be confirmed
+// with ConfirmPendingImport.
+func (m *Manager) ScanLibrary(ctx context.Context, finder MovieFinder, root string) (ScanResult, error) {
+	var result ScanResult
+
+	files, err := libraryFiles(root)
+	if err != nil {
+		return result, errors.Wrap(err, "libraryFiles")
+	}
+	known, err := m.knownPaths()
+	if err != nil {
+		return result, errors.Wrap(err, "m.knownPaths")
+	}
+
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+
+		result.Scanned++
+		if known[file.Path] {
+			result.Skipped++
+			continue
+		}
+
+		candidates, unique, err := matchCandidates(finder, file)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", file.Path, err))
+			continue
+		}
+
+		if unique {
+			movie, err := m.addLibraryMovie(finder, candidates[0].TmdbId, file)
+			if err == nil {
+				log.Printf("library scan: %s matched %s\n", file.Path, movie.ImdbId)
+				result.Imported++
+				continue
+			}
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", file.Path, err))
+			continue
+		}
+
+		pending := models.PendingImport{
+			ID:          pendingImportID(file.Path),
+			Path:        file.Path,
+			Size:        file.Size,
+			Quality:     file.Quality,
+			ParsedTitle: file.Title,
+			ParsedYear:  file.Year,
+			Candidates:  candidates,
+			CreatedAt:   time.Now().UTC(),
+		}
+		if err := m.Pending.Save(ctx, &pending); err != nil {
+			return result, errors.Wrap(err, "m.Pending.Save")
+		}
+		result.Pending++
+	}
+
+	return result, nil
+}
+
+func (m *Manager) PendingImports() ([]models.PendingImport, error) {
+	var pending []models.PendingImport
+	if err := m.Pending.FindAll(context.Background(), &pending); err != nil {
+		return nil, errors.Wrap(err, "m.Pending.FindAll")
+	}
+
+	return pending, nil
+}
+
+// ConfirmPendingImport adds the file of a pending import as the given themoviedb movie, which does not
+// need to be one of the candidates found by the scanner
+func (m *Manager) ConfirmPendingImport(finder MovieFinder, id string, tmdbID int32) (models.Movie, error) {
+	var pending models.PendingImport
+	if err := m.Pending.FindByID(context.Background(), &pending, id); err != nil {
+		return models.Movie{}, errors.Wrap(err, "m.Pending.FindByID")
+	}
+
+	file := libraryFile{Path: pending.Path, Size: pending.Size, Title: pending.ParsedTitle, Year: pending.ParsedYear, Quality: pending.Quality}
+	movie, err := m.addLibraryMovie(finder, tmdbID, file)
+	if err != nil {
+		return models.Movie{}, errors.Wrap(err, "m.addLibraryMovie")
+	}
+
+	if err := m.Pending.Delete(context.Background(), id); err != nil {
+		return movie, errors.Wrap(err, "m.Pending.Delete")
+	}
+
+	return movie, nil
+}
+
+// DismissPendingImport forgets a pending import without adding a movie
+func (m *Manager) DismissPendingImport(id string) error {
+	if err := m.Pending.Delete(context.Background(), id); err != nil {
+		return errors.Wrap(err, "m.Pending.Delete")
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"pomegranate/themoviedb"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// fakeFinder answers searches from a fixed list of movies
+type fakeFinder struct {
+	searches map[string][]themoviedb.Entry
+	imdbIds  map[int32]string
+}
+
+func (f fakeFinder) ReadMovies(search string, page int) (themoviedb.Response, error) {
+	return themoviedb.Response{Results: f.searches[search]}, nil
+}
+
+func (f fakeFinder) ReadSingleMovie(key string) (themoviedb.SingleMovieResponse, error) {
+	for id, imdbId := range f.imdbIds {
+		if fmt.Sprintf("%d", id) == key {
+			return themoviedb.SingleMovieResponse{Id: id, ImdbId: imdbId, Title: "Movie " + key, ReleaseDate: "2000-01-01"}, nil
+		}
+	}
+
+	return themoviedb.SingleMovieResponse{}, errors.Errorf("unknown movie %s", key)
+}
+
+func TestManager_ScanLibrary(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	root := t.TempDir()
+	files := []string{
+		"The Matrix (1999)/The.Matrix.1999.1080p.BluRay.x264-GRP.mkv",
+		"The Matrix (1999)/Sample/sample.mkv",
+		"Dune/dune.720p.mkv",
+		"Heat.1995.2160p.WEB-DL.mkv",
+		"Documents/notes.txt",
+	}
+	for _, file := range files {
+		path := filepath.Join(root, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+			t.Fatalf("os.MkdirAll: %s", err)
+		}
+		if err := ioutil.WriteFile(path, []byte("movie"), 0664); err != nil {
+			t.Fatalf("ioutil.WriteFile: %s", err)
+		}
+	}
+
+	finder := fakeFinder{
+		searches: map[string][]themoviedb.Entry{
+			"The Matrix": {
+				{Id: 603, Title: "The Matrix", ReleaseDate: "1999-03-30"},
+				{Id: 604, Title: "The Matrix Reloaded", ReleaseDate: "2003-05-15"},
+			},
+			"Dune": {
+				{Id: 438631, Title: "Dune", ReleaseDate: "2021-09-15"},
+				{Id: 841, Title: "Dune", ReleaseDate: "1984-12-14"},
+			},
+			"Heat": {
+				{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15"},
+				{Id: 1000, Title: "Heat", ReleaseDate: "1986-03-14"},
+			},
+		},
+		imdbIds: map[int32]string{603: "tt0133093", 949: "tt0113277", 841: "tt0087182"},
+	}
+
+	result, err := m.ScanLibrary(context.Background(), finder, root)
+	if err != nil {
+		t.Fatalf("m.ScanLibrary: %s", err)
+	}
+	if result.Scanned != 3 || result.Imported != 2 || result.Pending != 1 || len(result.Errors) != 0 {
+		t.Errorf("unexpected scan result: %+v", result)
+	}
+
+	matrix, err := m.Movie("tt0133093")
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if !matrix.Downloaded() || matrix.File.Quality != "1080p BluRay" {
+		t.Errorf("unexpected movie file: %+v", matrix.File)
+	}
+	if expected := filepath.Join(root, "The Matrix (1999)", "The.Matrix.1999.1080p.BluRay.x264-GRP.mkv"); matrix.File.Path != expected {
+		t.Errorf("expected path %s, got %s", expected, matrix.File.Path)
+	}
+	if _, err := m.Movie("tt0113277"); err != nil {
+		t.Errorf("heat was not imported: %s", err)
+	}
+
+	pending, err := m.PendingImports()
+	if err != nil {
+		t.Fatalf("m.PendingImports: %s", err)
+	}
+	if len(pending) != 1 || pending[0].ParsedTitle != "Dune" || len(pending[0].Candidates) != 2 {
+		t.Fatalf("unexpected pending imports: %+v", pending)
+	}
+
+	// a second scan skips the files it already knows
+	result, err = m.ScanLibrary(context.Background(), finder, root)
+	if err != nil {
+		t.Fatalf("m.ScanLibrary: %s", err)
+	}
+	if result.Skipped != 3 || result.Imported != 0 || result.Pending != 0 {
+		t.Errorf("unexpected second scan result: %+v", result)
+	}
+
+	dune, err := m.ConfirmPendingImport(finder, pending[0].ID, 841)
+	if err != nil {
+		t.Fatalf("m.ConfirmPendingImport: %s", err)
+	}
+	if dune.ImdbId != "tt0087182" || dune.File == nil || dune.File.Path != pending[0].Path {
+		t.Errorf("unexpected confirmed movie: %+v", dune)
+	}
+
+	pending, err = m.PendingImports()
+	if err != nil {
+		t.Fatalf("m.PendingImports: %s", err)
+	}
+	if len(pending) != 0 {
+		t.Errorf("pending import was not removed: %+v", pending)
+	}
+}
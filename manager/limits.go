@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/models"
+	"pomegranate/newznab"
+
+	"github.com/pkg/errors"
+)
+
+// IndexerQuota is the usage of an indexer during the current day, against its limits.
+// A zero limit means there is none.
+type IndexerQuota struct {
+	ApiHits   int       `json:"api_hits"`
+	ApiLimit  int       `json:"api_limit"`
+	Grabs     int       `json:"grabs"`
+	GrabLimit int       `json:"grab_limit"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+func (q IndexerQuota) apiExhausted() bool {
+	return q.ApiLimit > 0 && q.ApiHits >= q.ApiLimit
+}
+
+// apiError describes an exhausted api limit
+func (q IndexerQuota) apiError() error {
+	return errors.Wrapf(ErrQuotaExhausted, "limit of %d api hits reached until %s", q.ApiLimit, q.ResetsAt.Format(time.RFC3339))
+}
+
+func (q IndexerQuota) grabExhausted() bool {
+	return q.GrabLimit > 0 && q.Grabs >= q.GrabLimit
+}
+
+// usageDay returns the day counters are kept for, in UTC
+func usageDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// indexerUsage reads the counters of an indexer for the current day. Counters of a previous day are reset.
+func (m *Manager) indexerUsage(ctx context.Context, host string) (models.IndexerUsage, error) {
+	today := usageDay(time.Now())
+	usage := models.IndexerUsage{Host: host, Day: today}
+
+	var stored models.IndexerUsage
+	err := m.IndexerUsage.FindByID(ctx, &stored, host)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return usage, nil
+	case err != nil:
+		return usage, errors.Wrap(err, "m.IndexerUsage.FindByID")
+	case stored.Day != today:
+		// the limits reported by the indexer still apply
+		usage.ApiMax = stored.ApiMax
+		usage.GrabMax = stored.GrabMax
+		return usage, nil
+	}
+
+	return stored, nil
+}
+
+// updateIndexerUsage applies fn to the counters of the current day and saves them
+func (m *Manager) updateIndexerUsage(host string, fn func(usage *models.IndexerUsage)) error {
+	ctx := context.Background()
+
+	m.usageMu.Lock()
+	defer m.usageMu.Unlock()
+
+	usage, err := m.indexerUsage(ctx, host)
+	if err != nil {
+		return err
+	}
+	fn(&usage)
+	usage.UpdatedAt = time.Now().UTC()
+
+	if err := m.IndexerUsage.Save(ctx, &usage); err != nil {
+		return errors.Wrap(err, "m.IndexerUsage.Save")
+	}
+
+	return nil
+}
+
+// recordApiHit counts an api call. Counters reported by the indexer replace the local ones, as the
+// indexer also counts the calls made by other applications.
+func (m *Manager) recordApiHit(host string, limits *newznab.Limits) {
+	err := m.updateIndexerUsage(host, func(usage *models.IndexerUsage) {
+		if limits == nil {
+			usage.ApiHits++
+			return
+		}
+		usage.ApiHits = limits.ApiCurrent
+		usage.Grabs = limits.GrabCurrent
+		usage.ApiMax = limits.ApiMax
+		usage.GrabMax = limits.GrabMax
+	})
+	if err != nil {
+		log.Printf("cannot record api hit of %s: %s\n", host, err)
+	}
+}
+
+// recordGrab counts a release downloaded from an indexer
+func (m *Manager) recordGrab(host string) {
+	err := m.updateIndexerUsage(host, func(usage *models.IndexerUsage) {
+		usage.Grabs++
+	})
+	if err != nil {
+		log.Printf("cannot record grab of %s: %s\n", host, err)
+	}
+}
+
+// indexerQuota returns the usage of an indexer against its limits: the configured ones, or else the
+// ones the indexer reported
+func (m *Manager) indexerQuota(ctx context.Context, n newznab.Newznab) (IndexerQuota, error) {
+	usage, err := m.indexerUsage(ctx, n.Host)
+	if err != nil {
+		return IndexerQuota{}, err
+	}
+
+	day, err := time.Parse("2006-01-02", usage.Day)
+	if err != nil {
+		return IndexerQuota{}, errors.Wrap(err, "time.Parse")
+	}
+	quota := IndexerQuota{
+		ApiHits:   usage.ApiHits,
+		ApiLimit:  n.ApiLimit,
+		Grabs:     usage.Grabs,
+		GrabLimit: n.GrabLimit,
+		ResetsAt:  day.Add(24 * time.Hour),
+	}
+	if quota.ApiLimit <= 0 {
+		quota.ApiLimit = usage.ApiMax
+	}
+	if quota.GrabLimit <= 0 {
+		quota.GrabLimit = usage.GrabMax
+	}
+
+	return quota, nil
+}
+
+// counted returns a copy of the indexer recording its api calls, which refuses to make any more of them
+// once its api limit is reached
+func (m *Manager) counted(n newznab.Newznab) newznab.Newznab {
+	n.BeforeRequest = func(ctx context.Context) error {
+		quota, err := m.indexerQuota(ctx, n)
+		if err != nil {
+			return errors.Wrap(err, "m.indexerQuota")
+		}
+		if quota.apiExhausted() {
+			return quota.apiError()
+		}
+		return nil
+	}
+	n.OnResponse = func(limits *newznab.Limits) {
+		m.recordApiHit(n.Host, limits)
+	}
+
+	return n
+}
+
+// releaseIndexer returns the configured indexer a release is downloaded from: the one hosting its url,
+// or else the first indexer it was found on
+func (m *Manager) releaseIndexer(nzb models.NzbInfo) (newznab.Newznab, bool) {
+	if u, err := url.Parse(nzb.URL); err == nil {
+		for _, n := range m.Indexers {
+			if u.Host == n.Host {
+				return n, true
+			}
+		}
+	}
+	if len(nzb.Sources) > 0 {
+		for _, n := range m.Indexers {
+			if n.Host == nzb.Sources[0] {
+				return n, true
+			}
+		}
+	}
+
+	return newznab.Newznab{}, false
+}
+
+// checkGrabQuota returns ErrQuotaExhausted if the indexer of a release reached its grab limit
+func (m *Manager) checkGrabQuota(nzb models.NzbInfo) error {
+	n, ok := m.releaseIndexer(nzb)
+	if !ok {
+		return nil
+	}
+
+	quota, err := m.indexerQuota(context.Background(), n)
+	if err != nil {
+		return errors.Wrap(err, "m.indexerQuota")
+	}
+	if quota.grabExhausted() {
+		return errors.Wrapf(ErrQuotaExhausted, "%s reached its limit of %d grabs until %s", n.Host, quota.GrabLimit, quota.ResetsAt.Format(time.RFC3339))
+	}
+
+	return nil
+}
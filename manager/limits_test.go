@@ -0,0 +1,195 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"pomegranate/sabnzbd"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestManager_ApiLimit(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	reportLimits := false
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "movie" {
+			http.Error(w, "unsupported", http.StatusNotImplemented)
+			return
+		}
+		requests++
+		limits := ""
+		if reportLimits {
+			limits = `<newznab:apilimits apicurrent="50" apimax="50" grabcurrent="2" grabmax="10" />`
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel><newznab:response offset="0" total="0" />` + limits + `</channel></rss>`))
+	}))
+	defer server.Close()
+	n := newznab.Newznab{Host: strings.TrimPrefix(server.URL, "http://"), ApiLimit: 2}
+	m.Indexers = []newznab.Newznab{n}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	for i := 0; i < 2; i++ {
+		if _, err := m.SearchReleases(context.Background(), &movie); err != nil {
+			t.Fatalf("m.SearchReleases: %s", err)
+		}
+	}
+
+	_, err := m.SearchReleases(context.Background(), &movie)
+	if err != nil || requests != 2 {
+		t.Errorf("indexer over its api limit should be skipped: %d requests, err %v", requests, err)
+	}
+	if result := movie.LastSearch.Indexers[0]; !result.Skipped || !strings.Contains(result.Error, ErrQuotaExhausted.Error()) {
+		t.Errorf("unexpected result in search report: %+v", result)
+	}
+
+	// the counters of a previous day do not count
+	yesterday := models.IndexerUsage{Host: n.Host, Day: usageDay(time.Now().Add(-24 * time.Hour)), ApiHits: 2}
+	if err := m.IndexerUsage.Save(context.Background(), &yesterday); err != nil {
+		t.Fatalf("m.IndexerUsage.Save: %s", err)
+	}
+	quota, err := m.indexerQuota(context.Background(), n)
+	if err != nil {
+		t.Fatalf("m.indexerQuota: %s", err)
+	}
+	if quota.ApiHits != 0 || quota.ApiLimit != 2 || quota.ResetsAt.Before(time.Now()) || quota.ResetsAt.After(time.Now().Add(24*time.Hour)) {
+		t.Errorf("unexpected quota after the daily reset: %+v", quota)
+	}
+
+	// limits reported by the indexer apply when none is configured
+	m.Indexers[0].ApiLimit = 0
+	reportLimits = true
+	if _, err := m.SearchReleases(context.Background(), &movie); err != nil {
+		t.Fatalf("m.SearchReleases: %s", err)
+	}
+	health, err := m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if q := health[0].Quota; q.ApiHits != 50 || q.ApiLimit != 50 || q.Grabs != 2 || q.GrabLimit != 10 {
+		t.Errorf("unexpected quota from reported limits: %+v", q)
+	}
+	if _, err := m.SearchReleases(context.Background(), &movie); err != nil || !movie.LastSearch.Indexers[0].Skipped {
+		t.Errorf("expected the reported limit to be enforced, got %v: %+v", err, movie.LastSearch)
+	}
+}
+
+func TestManager_ApiLimitPerRequest(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	// thirty results, in pages of ten
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "movie" {
+			http.Error(w, "unsupported", http.StatusNotImplemented)
+			return
+		}
+		requests++
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		items := ""
+		for i := offset; i < offset+10; i++ {
+			items += fmt.Sprintf(`<item><title>The.Matrix.1999.1080p.BluRay.x264-GRP%d</title><guid>guid-%d</guid>
+<enclosure url="http://indexer/%d" length="100" type="application/x-nzb" /></item>`, i, i, i)
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel><newznab:response offset="` + strconv.Itoa(offset) + `" total="30" />` + items + `</channel></rss>`))
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	m.Indexers = []newznab.Newznab{{Host: host, ApiLimit: 1}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	added, err := m.SearchReleases(context.Background(), &movie)
+	if err != nil {
+		t.Fatalf("m.SearchReleases: %s", err)
+	}
+	if requests != 1 || added != 10 {
+		t.Errorf("the limit should stop the search after the first page: %d requests, %d releases", requests, added)
+	}
+	if result := movie.LastSearch.Indexers[0]; !result.Skipped || result.Found != 10 || !strings.Contains(result.Error, ErrQuotaExhausted.Error()) {
+		t.Errorf("unexpected result in search report: %+v", result)
+	}
+
+	// reaching the limit is not a failure of the indexer
+	health, err := m.IndexerHealth()
+	if err != nil {
+		t.Fatalf("m.IndexerHealth: %s", err)
+	}
+	if health[0].State != IndexerHealthy || health[0].Quota.ApiHits != 1 {
+		t.Errorf("unexpected health: %+v", health[0])
+	}
+
+	// nor does it fail adding a movie
+	finder := fakeFinder{imdbIds: map[int32]string{603: "tt0133093"}}
+	saved, err := m.SaveMovie(finder, "603", "", false)
+	if err != nil || requests != 1 {
+		t.Fatalf("adding a movie over the api limit: %d requests, err %v", requests, err)
+	}
+	if saved.LastSearch == nil || saved.LastSearch.Failed() {
+		t.Errorf("unexpected search report of the added movie: %+v", saved.LastSearch)
+	}
+}
+
+func TestManager_GrabLimit(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	var added []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		added = append(added, r.URL.Query().Get("name"))
+		_, _ = w.Write([]byte(`{"status": true, "nzo_ids": ["nzo_1"]}`))
+	}))
+	defer server.Close()
+	m.Downloader = sabnzbd.New(strings.TrimPrefix(server.URL, "http://"), "")
+	m.Indexers = []newznab.Newznab{{Host: "limited", GrabLimit: 1}, {Host: "other"}}
+
+	movie := models.Movie{
+		ImdbId: "tt0133093",
+		Title:  "The Matrix",
+		NzbInfo: []models.NzbInfo{
+			{ID: "first", GUID: "guid-first", URL: "http://limited/first", Title: "The.Matrix.1999.1080p.BluRay.x264-GRP", Status: models.StatusUnknown},
+			{ID: "best", GUID: "guid-best", URL: "http://downloads.limited/best", Title: "The.Matrix.1999.1080p.BluRay.x264-GRP2", Status: models.StatusUnknown, Sources: []string{"limited"}},
+			{ID: "next", GUID: "guid-next", URL: "http://other/next", Title: "The.Matrix.1999.720p.BluRay.x264-GRP", Status: models.StatusUnknown, Sources: []string{"other"}},
+		},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	if _, err := m.Grab(&movie, "first"); err != nil {
+		t.Fatalf("m.Grab: %s", err)
+	}
+	if _, err := m.Grab(&movie, "best"); !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("expected the grab limit to be enforced, got %v", err)
+	}
+
+	// the best release on an indexer with grabs left is picked
+	movie.NzbInfo[0].Status = models.StatusFailed
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+	nzb, err := m.GrabBest(&movie)
+	if err != nil {
+		t.Fatalf("m.GrabBest: %s", err)
+	}
+	if nzb == nil || nzb.ID != "next" {
+		t.Errorf("expected the release of the other indexer to be grabbed, got %+v", nzb)
+	}
+	if len(added) != 2 || added[0] != "http://limited/first" || added[1] != "http://other/next" {
+		t.Errorf("unexpected grabs: %v", added)
+	}
+}
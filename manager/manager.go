@@ -1,20 +1,75 @@
 package manager
 
 import (
+	"sync"
+	"time"
+
 	"pomegranate/database"
+	"pomegranate/downloader"
+	"pomegranate/importer"
 	"pomegranate/models"
+	"pomegranate/newznab"
+
+	"github.com/pkg/errors"
 )
 
 type Manager struct {
 	*database.DB
 
-	Movies database.Store
+	Movies          database.Store
+	Profiles        database.Store
+	Blocklisted     database.Store
+	Pending         database.Store // Library files waiting for a manual match
+	History         database.Store // Append-only log of movie events
+	Capabilities    database.Store // Cached newznab capabilities, by indexer host
+	IndexerStatuses database.Store // Failures and backoff of the indexers, by host
+	IndexerUsage    database.Store // Daily api hits and grabs of the indexers, by host
+	Indexers        []newznab.Newznab
+	Downloader      downloader.Downloader // nil if no downloader is configured
+	Importer        *importer.Importer    // nil if no library is configured
+	Events          *EventBus
+
+	// IndexerTimeout bounds the searches of a movie on a single indexer, DefaultIndexerTimeout when zero
+	IndexerTimeout time.Duration
+
+	healthMu sync.Mutex // Serializes the updates of the indexer statuses
+	usageMu  sync.Mutex // Serializes the updates of the indexer usage
+
+	// DeleteFailed removes failed jobs, and their files, from the downloader
+	DeleteFailed bool
 }
 
 func NewManager(db *database.DB) (*Manager, error) {
 	m := &Manager{
-		DB:     db,
-		Movies: database.NewStore(db, &models.Movie{}),
+		DB:       db,
+		Movies:   database.NewStore(db, &models.Movie{}),
+		Profiles: database.NewStore(db, &models.QualityProfile{}),
+
+		Blocklisted:     database.NewStore(db, &models.BlocklistEntry{}),
+		Pending:         database.NewStore(db, &models.PendingImport{}),
+		History:         database.NewStore(db, &models.HistoryEntry{}),
+		Capabilities:    database.NewStore(db, &models.IndexerCaps{}),
+		IndexerStatuses: database.NewStore(db, &models.IndexerStatus{}),
+		IndexerUsage:    database.NewStore(db, &models.IndexerUsage{}),
+		Events:          NewEventBus(),
+	}
+
+	for _, bucket := range []string{models.ProfileKind, models.BlocklistKind, models.PendingImportKind, models.SettingsKind, models.HistoryKind, models.IndexerCapsKind, models.IndexerStatusKind, models.IndexerUsageKind} {
+		if err := db.CreateBucket(bucket); err != nil {
+			return nil, errors.Wrapf(err, "db.CreateBucket (%s)", bucket)
+		}
+	}
+	if err := db.RegisterIndexes(&models.Movie{}); err != nil {
+		return nil, errors.Wrap(err, "db.RegisterIndexes (movies)")
+	}
+	if err := db.RegisterIndexes(&models.HistoryEntry{}); err != nil {
+		return nil, errors.Wrap(err, "db.RegisterIndexes (history)")
+	}
+	if err := m.ensureDefaultProfile(); err != nil {
+		return nil, errors.Wrap(err, "m.ensureDefaultProfile")
+	}
+	if err := m.ensureApiKey(); err != nil {
+		return nil, errors.Wrap(err, "m.ensureApiKey")
 	}
 
 	return m, nil
@@ -0,0 +1,37 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"pomegranate/database"
+	"testing"
+)
+
+// newTestManager creates a manager backed by a database in a temporary directory.
+// The returned function closes the database and removes the directory.
+func newTestManager(t *testing.T) (*Manager, func()) {
+	tmp, err := ioutil.TempDir("", "managertest")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+
+	db, err := database.Open(path.Join(tmp, "database.db"))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+
+	m, err := NewManager(db)
+	if err != nil {
+		t.Fatalf("NewManager: %s", err)
+	}
+
+	return m, func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing database: %v", err)
+		}
+		if err := os.RemoveAll(tmp); err != nil {
+			t.Errorf("error cleaning temp dir: %v", err)
+		}
+	}
+}
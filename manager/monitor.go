@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"context"
+	"log"
+
+	"pomegranate/downloader"
+	"pomegranate/metrics"
+	"pomegranate/models"
+
+	"github.com/pkg/errors"
+)
+
+// updateFromJob applies the state reported by the downloader to a release. It returns true if anything changed.
+func updateFromJob(nzb *models.NzbInfo, job downloader.Job) bool {
+	before := *nzb
+
+	nzb.Progress = job.Progress
+	switch job.Status {
+	case downloader.JobCompleted:
+		nzb.Status = models.StatusSuccess
+		nzb.Progress = 100
+		nzb.StoragePath = job.StoragePath
+		nzb.FailMessage = ""
+	case downloader.JobFailed:
+		nzb.Status = models.StatusFailed
+		nzb.FailMessage = job.FailMessage
+	case downloader.JobDeleted:
+		nzb.Status = models.StatusDeleted
+	}
+
+	return before.Status != nzb.Status || before.Progress != nzb.Progress ||
+		before.StoragePath != nzb.StoragePath || before.FailMessage != nzb.FailMessage
+}
+
+// errUnchanged aborts an update that has nothing to write
+var errUnchanged = errors.New("unchanged")
+
+func hasSnatched(movie models.Movie) bool {
+	for _, nzb := range movie.NzbInfo {
+		if nzb.Status == models.StatusSnatched && nzb.DownloaderId != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyJobs updates the snatched releases of a movie from the downloader jobs. It returns whether anything
+// changed and the ids of the releases that failed or completed.
+func (m *Manager) applyJobs(movie *models.Movie, jobs map[string]downloader.Job) (changed bool, failed []string, completed []string) {
+	for i := range movie.NzbInfo {
+		nzb := &movie.NzbInfo[i]
+		if nzb.Status != models.StatusSnatched || nzb.DownloaderId == "" {
+			continue
+		}
+
+		job, ok := jobs[nzb.DownloaderId]
+		if !ok {
+			log.Printf("job %s for %s not found in %s\n", nzb.DownloaderId, movie.ImdbId, m.Downloader.Name())
+			continue
+		}
+
+		if updateFromJob(nzb, job) {
+			changed = true
+			if nzb.Status != models.StatusSnatched {
+				log.Printf("download of %s for %s: %s\n", nzb.Title, movie.ImdbId, job.Status)
+			}
+		}
+		switch nzb.Status {
+		case models.StatusFailed:
+			failed = append(failed, nzb.ID)
+		case models.StatusSuccess:
+			completed = append(completed, nzb.ID)
+		}
+	}
+
+	return changed, failed, completed
+}
+
+// retryImports imports again the completed releases whose import failed
+func (m *Manager) retryImports(movies []models.Movie) {
+	for _, movie := range movies {
+		for _, nzb := range movie.NzbInfo {
+			if nzb.Status != models.StatusSuccess || nzb.ImportError == "" {
+				continue
+			}
+			if err := m.ImportDownload(&movie, nzb.ID); err != nil {
+				log.Printf("cannot import download %s of %s: %s\n", nzb.ID, movie.ImdbId, err)
+			}
+		}
+	}
+}
+
+// MonitorDownloads matches every snatched release against the downloader queue and history, updating
+// their status, progress, storage path and failure message. Completed downloads are imported into the
+// library when one is configured; failed imports are retried on every call.
+func (m *Manager) MonitorDownloads(ctx context.Context) error {
+	if m.Downloader == nil {
+		return nil
+	}
+
+	movies, err := m.AllMovies()
+	if err != nil {
+		return errors.Wrap(err, "m.AllMovies")
+	}
+	if m.Importer != nil {
+		m.retryImports(movies)
+	}
+
+	var ids []string
+	for _, movie := range movies {
+		for _, nzb := range movie.NzbInfo {
+			if nzb.Status == models.StatusSnatched && nzb.DownloaderId != "" {
+				ids = append(ids, nzb.DownloaderId)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	queue, err := m.Downloader.QueueJobs(ids...)
+	if err != nil {
+		return errors.Wrapf(err, "%s.QueueJobs", m.Downloader.Name())
+	}
+	history, err := m.Downloader.HistoryJobs(ids...)
+	if err != nil {
+		return errors.Wrapf(err, "%s.HistoryJobs", m.Downloader.Name())
+	}
+
+	// a job may briefly show up in both lists while moving to the history, which has the most recent state
+	jobs := make(map[string]downloader.Job)
+	for _, job := range queue {
+		jobs[job.ID] = job
+	}
+	for _, job := range history {
+		jobs[job.ID] = job
+	}
+
+	for _, movie := range movies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !hasSnatched(movie) {
+			continue
+		}
+
+		// jobs are applied to the stored movie, so changes made since it was listed are kept
+		var failed, completed []string
+		err := m.Movies.Update(ctx, movie.ImdbId, func(dst interface{}) error {
+			stored := dst.(*models.Movie)
+
+			var changed bool
+			changed, failed, completed = m.applyJobs(stored, jobs)
+			if !changed {
+				return errUnchanged
+			}
+			movie = *stored
+
+			return nil
+		})
+		if err == errUnchanged {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "m.Movies.Update (%s)", movie.ImdbId)
+		}
+		for _, nzb := range movie.NzbInfo {
+			if nzb.Status == models.StatusSnatched && nzb.DownloaderId != "" && m.Events != nil {
+				m.Events.Publish(Event{Type: EventDownloadProgress, ImdbId: movie.ImdbId, Title: movie.Title, NzbID: nzb.ID, Progress: nzb.Progress})
+			}
+		}
+		for _, id := range completed {
+			m.recordHistory(models.EventDownloadCompleted, models.ActorSystem, movie, id, models.StatusSnatched, models.StatusSuccess)
+		}
+		for _, id := range failed {
+			metrics.DownloadFailures.Inc()
+			after := models.StatusFailed
+			if nzb := movie.Release(id); nzb != nil && nzb.FailMessage != "" {
+				after += ": " + nzb.FailMessage
+			}
+			m.recordHistory(models.EventDownloadFailed, models.ActorSystem, movie, id, models.StatusSnatched, after)
+		}
+
+		if m.Importer != nil {
+			for _, id := range completed {
+				if err := m.ImportDownload(&movie, id); err != nil {
+					log.Printf("cannot import download %s of %s: %s\n", id, movie.ImdbId, err)
+				}
+			}
+		}
+		for _, id := range failed {
+			if err := m.HandleFailedDownload(&movie, id); err != nil {
+				log.Printf("cannot handle failed download %s of %s: %s\n", id, movie.ImdbId, err)
+			}
+		}
+	}
+
+	return nil
+}
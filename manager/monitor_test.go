@@ -0,0 +1,120 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/downloader"
+	"pomegranate/models"
+	"pomegranate/sabnzbd"
+	"strings"
+	"testing"
+)
+
+// fakeSabnzbd answers queue and history requests with the given payloads
+func fakeSabnzbd(t *testing.T, queue string, history string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload string
+		switch mode := r.URL.Query().Get("mode"); mode {
+		case "queue":
+			payload = queue
+		case "history":
+			payload = history
+		default:
+			t.Errorf("unexpected mode: %s", mode)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(payload)); err != nil {
+			t.Errorf("w.Write: %s", err)
+		}
+	}))
+}
+
+func TestManager_MonitorDownloads(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	server := fakeSabnzbd(t,
+		`{"queue": {"slots": [{"nzo_id": "nzo_queued", "status": "Downloading", "percentage": "42"}]}}`,
+		`{"history": {"slots": [
+			{"nzo_id": "nzo_completed", "status": "Completed", "storage": "/complete/movie"},
+			{"nzo_id": "nzo_failed", "status": "Failed", "fail_message": "Out of retention"},
+			{"nzo_id": "nzo_extracting", "status": "Extracting"}
+		]}}`,
+	)
+	defer server.Close()
+	m.Downloader = sabnzbd.New(strings.TrimPrefix(server.URL, "http://"), "")
+
+	movie := models.Movie{
+		ImdbId: "tt0133093",
+		Title:  "The Matrix",
+		NzbInfo: []models.NzbInfo{
+			{ID: "queued", Status: models.StatusSnatched, DownloaderId: "nzo_queued"},
+			{ID: "completed", Status: models.StatusSnatched, DownloaderId: "nzo_completed"},
+			{ID: "failed", Status: models.StatusSnatched, DownloaderId: "nzo_failed"},
+			{ID: "extracting", Status: models.StatusSnatched, DownloaderId: "nzo_extracting"},
+			{ID: "available", Status: models.StatusUnknown},
+		},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	if err := m.MonitorDownloads(context.Background()); err != nil {
+		t.Fatalf("m.MonitorDownloads: %s", err)
+	}
+
+	updated, err := m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+
+	testCases := []struct {
+		id          string
+		status      models.NzbStatus
+		progress    float64
+		storagePath string
+		failMessage string
+	}{
+		{"queued", models.StatusSnatched, 42, "", ""},
+		{"completed", models.StatusSuccess, 100, "/complete/movie", ""},
+		{"failed", models.StatusFailed, 100, "", "Out of retention"},
+		{"extracting", models.StatusSnatched, 100, "", ""},
+		{"available", models.StatusUnknown, 0, "", ""},
+	}
+	for _, testCase := range testCases {
+		nzb := updated.Release(testCase.id)
+		if nzb == nil {
+			t.Fatalf("release %s is missing", testCase.id)
+		}
+		if nzb.Status != testCase.status || nzb.Progress != testCase.progress || nzb.StoragePath != testCase.storagePath || nzb.FailMessage != testCase.failMessage {
+			t.Errorf("unexpected state for %s: %+v", testCase.id, *nzb)
+		}
+	}
+}
+
+func TestManager_ApplyJobsDeleted(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	movie := models.Movie{
+		ImdbId:  "tt0133093",
+		NzbInfo: []models.NzbInfo{{ID: "deleted", Status: models.StatusSnatched, DownloaderId: "7"}},
+	}
+	jobs := map[string]downloader.Job{"7": {ID: "7", Status: downloader.JobDeleted, Progress: 100}}
+
+	changed, failed, completed := m.applyJobs(&movie, jobs)
+	if !changed || len(failed) != 0 || len(completed) != 0 {
+		t.Errorf("a job deleted by hand is not a failure: changed %t, failed %v, completed %v", changed, failed, completed)
+	}
+	if status := movie.NzbInfo[0].Status; status != models.StatusDeleted {
+		t.Errorf("unexpected status %s", status)
+	}
+	// another release is not grabbed in its place
+	if !movie.Grabbed() {
+		t.Errorf("a movie whose job was deleted by hand should not be grabbed again")
+	}
+}
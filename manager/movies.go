@@ -2,13 +2,14 @@ package manager
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
+	"pomegranate/database"
 	"pomegranate/models"
 	"pomegranate/themoviedb"
+	"regexp"
 
 	"github.com/pkg/errors"
-	bolt "go.etcd.io/bbolt"
 )
 
 type MovieEntry struct {
@@ -57,39 +58,28 @@ func (m *Manager) MovieSearch(tmdb themoviedb.Themoviedb, query string) ([]Movie
 	return payload, nil
 }
 
+// MovieWithNzbID returns the movie holding the release with the given id, or database.ErrNotFound
 func (m *Manager) MovieWithNzbID(id string) (models.Movie, error) {
-	if m.DB.Database == nil {
-		return models.Movie{}, errors.New("database was not initialized")
-	}
-
-	var resp models.Movie
-	err := m.DB.Database.View(func(tx *bolt.Tx) error {
-		// Assume bucket exists and has keys
-		b := tx.Bucket([]byte(resp.Kind()))
-
-		c := b.Cursor()
+	return m.movieByIndex(models.NzbIDIndex, id)
+}
 
-		for k, bytes := c.First(); k != nil; k, bytes = c.Next() {
-			var m models.Movie
-			if err := json.Unmarshal(bytes, &m); err != nil {
-				return errors.Wrap(err, "json.Unmarshal")
-			}
+// MovieWithNzbGUID returns the movie holding the release with the given indexer guid, or database.ErrNotFound
+func (m *Manager) MovieWithNzbGUID(guid string) (models.Movie, error) {
+	return m.movieByIndex(models.NzbGUIDIndex, guid)
+}
 
-			for _, info := range m.NzbInfo {
-				if info.ID == id {
-					resp = m
-					return nil
-				}
-			}
-		}
+// MovieWithDownloaderID returns the movie whose release became the given downloader job, or database.ErrNotFound
+func (m *Manager) MovieWithDownloaderID(id string) (models.Movie, error) {
+	return m.movieByIndex(models.DownloaderIDIndex, id)
+}
 
-		return nil
-	})
-	if err != nil {
-		return models.Movie{}, errors.Wrap(err, "m.DB.Database.View")
+func (m *Manager) movieByIndex(index string, value string) (models.Movie, error) {
+	var movie models.Movie
+	if err := m.Movies.FindByIndex(context.Background(), &movie, index, value); err != nil {
+		return models.Movie{}, errors.Wrapf(err, "m.Movies.FindByIndex (%s)", index)
 	}
 
-	return resp, nil
+	return movie, nil
 }
 
 func (m *Manager) AllMovies() ([]models.Movie, error) {
@@ -118,3 +108,224 @@ func (m *Manager) Movie(key string) (models.Movie, error) {
 
 	return movie, nil
 }
+
+var movieIdentifier = regexp.MustCompile(`^(tt\d+|\d+)$`)
+
+// fetchMovie reads the details of a movie from themoviedb, given either its imdb or themoviedb id
+func fetchMovie(finder MovieFinder, identifier string) (themoviedb.SingleMovieResponse, error) {
+	if !movieIdentifier.MatchString(identifier) {
+		return themoviedb.SingleMovieResponse{}, errors.Wrapf(ErrInvalid, "%q is neither an imdb nor a themoviedb id", identifier)
+	}
+
+	details, err := finder.ReadSingleMovie(identifier)
+	if err != nil {
+		return details, errors.Wrap(upstream("themoviedb", err), "finder.ReadSingleMovie")
+	}
+	if details.ImdbId == "" {
+		return details, errors.Wrapf(database.ErrNotFound, "movie %s on themoviedb", identifier)
+	}
+
+	return details, nil
+}
+
+// applyDetails copies the themoviedb metadata into a movie
+func applyDetails(movie *models.Movie, details themoviedb.SingleMovieResponse) {
+	movie.ImdbId = details.ImdbId
+	movie.Title = details.Title
+	movie.AlternativeTitles = nil
+	for _, alternative := range details.AlternativeTitles.Titles {
+		if !containsTitle(append([]string{movie.Title}, movie.AlternativeTitles...), alternative.Title) {
+			movie.AlternativeTitles = append(movie.AlternativeTitles, alternative.Title)
+		}
+	}
+	movie.Overview = details.Overview
+	movie.ReleaseDate = details.ReleaseDate
+	movie.Runtime = details.Runtime
+}
+
+// storeDetails writes the themoviedb metadata of a movie, and its profile when profileID is not empty.
+// Existing movies are updated in place, keeping the changes made to their releases meanwhile.
+func (m *Manager) storeDetails(movie *models.Movie, details themoviedb.SingleMovieResponse, profileID string, created bool) error {
+	ctx := context.Background()
+	if created {
+		applyDetails(movie, details)
+		if profileID != "" {
+			movie.ProfileID = profileID
+		}
+		if err := m.Movies.Save(ctx, movie); err != nil {
+			return errors.Wrap(err, "m.Movies.Save")
+		}
+		return nil
+	}
+
+	err := m.Movies.Update(ctx, movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		applyDetails(stored, details)
+		if profileID != "" {
+			stored.ProfileID = profileID
+		}
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "m.Movies.Update")
+	}
+
+	return nil
+}
+
+// searchAndGrab looks for new releases of a stored movie, merges them into it and grabs the best release
+// if nothing was grabbed yet. The outcome of the search is recorded in the LastSearch of the movie: a
+// failed search is not an error. The releases are merged into the stored movie, as grabs or downloads may
+// have changed it during the search.
+func (m *Manager) searchAndGrab(movie *models.Movie) error {
+	ctx := context.Background()
+	items, report, searchErr := m.findReleases(ctx, *movie)
+
+	added := 0
+	err := m.Movies.Update(ctx, movie.ImdbId, func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		added = MergeReleases(stored, items)
+		stored.LastSearch = &report
+		*movie = *stored
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "m.Movies.Update")
+	}
+	// a failed search is recorded on the movie, which is searched again by the next scheduled search
+	if searchErr != nil {
+		log.Printf("cannot search releases for %s: %s\n", movie.ImdbId, searchErr)
+	}
+
+	if added > 0 {
+		before := releasesSummary(len(movie.NzbInfo) - added)
+		m.recordHistory(models.EventReleasesFound, models.ActorUser, *movie, "", before, releasesSummary(len(movie.NzbInfo)))
+	}
+	m.publish(EventSearchCompleted, *movie, "", fmt.Sprintf("%d new", added))
+
+	if _, err := m.GrabBest(movie); err != nil {
+		log.Printf("cannot grab a release for %s: %s\n", movie.ImdbId, err)
+	}
+
+	return nil
+}
+
+// SaveMovie adds a movie, given either its imdb or themoviedb id, searches its releases and grabs the best one.
+// An existing movie is refreshed instead, unless overwrite is false, when ErrAlreadyExists is returned.
+// The quality profile is only changed when profileID is not empty.
+func (m *Manager) SaveMovie(finder MovieFinder, identifier string, profileID string, overwrite bool) (models.Movie, error) {
+	details, err := fetchMovie(finder, identifier)
+	if err != nil {
+		return models.Movie{}, errors.Wrap(err, "fetchMovie")
+	}
+
+	movie, err := m.Movie(details.ImdbId)
+	if err == nil && !overwrite {
+		return movie, errors.Wrapf(ErrAlreadyExists, "movie %s", details.ImdbId)
+	}
+	created := errors.Is(err, database.ErrNotFound)
+	if err != nil && !created {
+		return movie, errors.Wrap(err, "m.Movie")
+	}
+
+	if profileID != "" {
+		if _, err := m.Profile(profileID); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return movie, errors.Wrapf(ErrInvalid, "unknown profile %s", profileID)
+			}
+			return movie, errors.Wrap(err, "m.Profile")
+		}
+	}
+
+	if err := m.storeDetails(&movie, details, profileID, created); err != nil {
+		return movie, errors.Wrap(err, "m.storeDetails")
+	}
+	if created {
+		m.recordHistory(models.EventMovieAdded, models.ActorUser, movie, "", "", movie.Title)
+	}
+	if err := m.searchAndGrab(&movie); err != nil {
+		return movie, errors.Wrap(err, "m.searchAndGrab")
+	}
+
+	return movie, nil
+}
+
+// RefreshMovie updates the metadata of a movie already in the database and searches for new releases
+func (m *Manager) RefreshMovie(finder MovieFinder, imdbId string) (models.Movie, error) {
+	movie, err := m.Movie(imdbId)
+	if err != nil {
+		return movie, errors.Wrap(err, "m.Movie")
+	}
+
+	details, err := fetchMovie(finder, imdbId)
+	if err != nil {
+		return movie, errors.Wrap(err, "fetchMovie")
+	}
+
+	if err := m.storeDetails(&movie, details, "", false); err != nil {
+		return movie, errors.Wrap(err, "m.storeDetails")
+	}
+	if err := m.searchAndGrab(&movie); err != nil {
+		return movie, errors.Wrap(err, "m.searchAndGrab")
+	}
+
+	return movie, nil
+}
+
+// DeleteMovie removes a movie from the database. Its files, in the library or in the downloader, are kept.
+func (m *Manager) DeleteMovie(imdbId string) error {
+	movie, err := m.Movie(imdbId)
+	if err != nil {
+		return errors.Wrap(err, "m.Movie")
+	}
+
+	if err := m.Movies.Delete(context.Background(), imdbId); err != nil {
+		return errors.Wrap(err, "m.Movies.Delete")
+	}
+	m.recordHistory(models.EventMovieDeleted, models.ActorUser, movie, "", movie.Title, "")
+
+	return nil
+}
+
+// Movie statuses counted by MovieCountsByStatus
+const (
+	MovieWanted     = "wanted"
+	MovieSnatched   = "snatched"
+	MovieDownloaded = "downloaded"
+)
+
+// MovieCountsByStatus counts the movies that are downloaded, snatched (being downloaded), or still wanted
+func (m *Manager) MovieCountsByStatus() (map[string]int, error) {
+	movies, err := m.AllMovies()
+	if err != nil {
+		return nil, errors.Wrap(err, "m.AllMovies")
+	}
+
+	counts := map[string]int{MovieWanted: 0, MovieSnatched: 0, MovieDownloaded: 0}
+	for _, movie := range movies {
+		switch {
+		case movie.Downloaded():
+			counts[MovieDownloaded]++
+		case movie.Grabbed():
+			counts[MovieSnatched]++
+		default:
+			counts[MovieWanted]++
+		}
+	}
+
+	return counts, nil
+}
+
+// ListMovies returns a page of movies, along with the total count and the cursor of the next page
+func (m *Manager) ListMovies(query database.Query) ([]models.Movie, database.PageInfo, error) {
+	var movies []models.Movie
+	info, err := m.Movies.FindPage(context.Background(), &movies, query)
+	if err != nil {
+		return nil, info, errors.Wrap(err, "m.Movies.FindPage")
+	}
+
+	return movies, info, nil
+}
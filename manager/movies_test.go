@@ -0,0 +1,219 @@
+package manager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/database"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"pomegranate/themoviedb"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestManager_SaveMovie(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	finder := fakeFinder{
+		searches: map[string][]themoviedb.Entry{},
+		imdbIds:  map[int32]string{603: "tt0133093"},
+	}
+
+	movie, err := m.SaveMovie(finder, "603", "", false)
+	if err != nil {
+		t.Fatalf("m.SaveMovie: %s", err)
+	}
+	if movie.ImdbId != "tt0133093" || movie.Title != "Movie 603" {
+		t.Errorf("unexpected movie: %+v", movie)
+	}
+
+	if _, err := m.SaveMovie(finder, "603", "", false); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+	if _, err := m.SaveMovie(finder, "603", "", true); err != nil {
+		t.Errorf("m.SaveMovie with overwrite: %s", err)
+	}
+
+	testCases := []struct {
+		identifier string
+		profileID  string
+		expected   error
+	}{
+		{identifier: "the matrix", expected: ErrInvalid},
+		{identifier: "603", profileID: "unknown", expected: ErrInvalid},
+		{identifier: "604", expected: &UpstreamError{}},
+	}
+	for _, tc := range testCases {
+		_, err := m.SaveMovie(finder, tc.identifier, tc.profileID, true)
+		if upstreamErr, ok := tc.expected.(*UpstreamError); ok {
+			if !errors.As(err, &upstreamErr) {
+				t.Errorf("%s: expected an upstream error, got %v", tc.identifier, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.identifier, tc.expected, err)
+		}
+	}
+}
+
+func TestManager_DeleteMovie(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	finder := fakeFinder{imdbIds: map[int32]string{603: "tt0133093"}}
+	if _, err := m.SaveMovie(finder, "603", "", false); err != nil {
+		t.Fatalf("m.SaveMovie: %s", err)
+	}
+
+	if err := m.DeleteMovie("tt0133093"); err != nil {
+		t.Fatalf("m.DeleteMovie: %s", err)
+	}
+	if _, err := m.Movie("tt0133093"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("expected the movie to be deleted, got %v", err)
+	}
+	if err := m.DeleteMovie("tt0133093"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := m.RefreshMovie(finder, "tt0133093"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("expected ErrNotFound on refresh, got %v", err)
+	}
+}
+
+func TestManager_MovieWithNzbID(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	movie := models.Movie{
+		ImdbId:  "tt0133093",
+		NzbInfo: []models.NzbInfo{{ID: "nzb1", GUID: "guid1", DownloaderId: "nzo_1"}},
+	}
+	if err := movie.Store(m.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+
+	lookups := map[string]func(string) (models.Movie, error){
+		"nzb1":  m.MovieWithNzbID,
+		"guid1": m.MovieWithNzbGUID,
+		"nzo_1": m.MovieWithDownloaderID,
+	}
+	for value, lookup := range lookups {
+		found, err := lookup(value)
+		if err != nil {
+			t.Errorf("%s: %s", value, err)
+		} else if found.ImdbId != movie.ImdbId {
+			t.Errorf("%s: expected %s, got %s", value, movie.ImdbId, found.ImdbId)
+		}
+	}
+
+	if _, err := m.MovieWithNzbID("unknown"); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestApplyDetails_AlternativeTitles(t *testing.T) {
+	details := themoviedb.SingleMovieResponse{ImdbId: "tt0133093", Title: "The Matrix"}
+	for _, title := range []string{"Matrix", "The Matrix", "matrix", "Matrix, The"} {
+		details.AlternativeTitles.Titles = append(details.AlternativeTitles.Titles, struct {
+			Iso31661 string `json:"iso_3166_1"`
+			Title    string `json:"title"`
+			Type     string `json:"type"`
+		}{Title: title})
+	}
+
+	movie := models.Movie{AlternativeTitles: []string{"stale"}}
+	applyDetails(&movie, details)
+
+	// titles equal to the main title or to another alternative, once normalized, are dropped
+	if len(movie.AlternativeTitles) != 2 || movie.AlternativeTitles[0] != "Matrix" || movie.AlternativeTitles[1] != "Matrix, The" {
+		t.Errorf("unexpected alternative titles: %v", movie.AlternativeTitles)
+	}
+}
+
+func TestManager_SaveMovieKeepsConcurrentChanges(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	finder := fakeFinder{imdbIds: map[int32]string{603: "tt0133093"}}
+	if _, err := m.SaveMovie(finder, "603", "", false); err != nil {
+		t.Fatalf("m.SaveMovie: %s", err)
+	}
+	err := m.Movies.Update(context.Background(), "tt0133093", func(dst interface{}) error {
+		stored := dst.(*models.Movie)
+		stored.NzbInfo = append(stored.NzbInfo, models.NzbInfo{ID: "known", GUID: "guid-known", URL: "http://indexer/known", Title: "Movie.603.2000.1080p.BluRay.x264-GRP", Status: models.StatusUnknown})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("m.Movies.Update: %s", err)
+	}
+
+	// the release is grabbed while the indexer is searched
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "movie" {
+			http.Error(w, "unsupported", http.StatusNotImplemented)
+			return
+		}
+		err := m.Movies.Update(context.Background(), "tt0133093", func(dst interface{}) error {
+			stored := dst.(*models.Movie)
+			stored.Release("known").Status = models.StatusSnatched
+			stored.Release("known").DownloaderId = "nzo_known"
+			return nil
+		})
+		if err != nil {
+			t.Errorf("m.Movies.Update: %s", err)
+		}
+		_, _ = w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><item><title>Movie.603.2000.720p.BluRay.x264-GRP</title><guid>guid-new</guid>
+<enclosure url="http://indexer/new" length="1" type="application/x-nzb" /></item></channel></rss>`))
+	}))
+	defer server.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(server.URL, "http://")}}
+
+	movie, err := m.SaveMovie(finder, "603", "", true)
+	if err != nil {
+		t.Fatalf("m.SaveMovie: %s", err)
+	}
+
+	stored, err := m.Movie(movie.ImdbId)
+	if err != nil {
+		t.Fatalf("m.Movie: %s", err)
+	}
+	if known := stored.Release("known"); known == nil || known.Status != models.StatusSnatched || known.DownloaderId != "nzo_known" {
+		t.Errorf("the grab made during the search was lost: %+v", known)
+	}
+	if len(stored.NzbInfo) != 2 || stored.NzbInfo[1].GUID != "guid-new" {
+		t.Errorf("unexpected releases: %+v", stored.NzbInfo)
+	}
+}
+
+func TestManager_SaveMovieFailedSearch(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(server.URL, "http://")}}
+
+	finder := fakeFinder{imdbIds: map[int32]string{603: "tt0133093"}}
+	movie, err := m.SaveMovie(finder, "603", "", false)
+	if err != nil {
+		t.Fatalf("a failed search should not fail the add: %s", err)
+	}
+	if movie.LastSearch == nil || !movie.LastSearch.Failed() {
+		t.Errorf("the failed search should be reported: %+v", movie.LastSearch)
+	}
+
+	stored, err := m.Movie("tt0133093")
+	if err != nil {
+		t.Fatalf("the movie should be stored: %s", err)
+	}
+	if stored.LastSearch == nil || len(stored.LastSearch.Indexers) != 1 || stored.LastSearch.Indexers[0].Error == "" {
+		t.Errorf("the search report should be stored: %+v", stored.LastSearch)
+	}
+}
@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"context"
+
+	"pomegranate/database"
+	"pomegranate/models"
+
+	"github.com/lsmoura/humantoken"
+	"github.com/pkg/errors"
+)
+
+// ensureDefaultProfile creates the default quality profile if it does not exist yet
+func (m *Manager) ensureDefaultProfile() error {
+	var profile models.QualityProfile
+	err := m.Profiles.FindByID(context.Background(), &profile, models.DefaultProfileID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, database.ErrNotFound) {
+		return errors.Wrap(err, "m.Profiles.FindByID")
+	}
+
+	profile = models.DefaultProfile
+	if err := m.Profiles.Save(context.Background(), &profile); err != nil {
+		return errors.Wrap(err, "m.Profiles.Save")
+	}
+
+	return nil
+}
+
+func (m *Manager) AllProfiles() ([]models.QualityProfile, error) {
+	var resp []models.QualityProfile
+
+	if err := m.Profiles.FindAll(context.Background(), &resp); err != nil {
+		return nil, errors.Wrap(err, "m.Profiles.FindAll")
+	}
+
+	return resp, nil
+}
+
+func (m *Manager) Profile(id string) (models.QualityProfile, error) {
+	if id == "" {
+		id = models.DefaultProfileID
+	}
+
+	var profile models.QualityProfile
+	if err := m.Profiles.FindByID(context.Background(), &profile, id); err != nil {
+		return models.QualityProfile{}, errors.Wrapf(err, "m.Profiles.FindByID (%s)", id)
+	}
+
+	return profile, nil
+}
+
+// SaveProfile creates or updates a quality profile. A new id is generated for profiles without one.
+func (m *Manager) SaveProfile(profile models.QualityProfile) (models.QualityProfile, error) {
+	if profile.Name == "" {
+		return profile, errors.Wrap(ErrInvalid, "profile name cannot be empty")
+	}
+	if profile.MaxSizePerMinute > 0 && profile.MinSizePerMinute > profile.MaxSizePerMinute {
+		return profile, errors.Wrap(ErrInvalid, "minimum size cannot be greater than maximum size")
+	}
+	if profile.ID == "" {
+		profile.ID = humantoken.Generate(8, nil)
+	}
+
+	if err := m.Profiles.Save(context.Background(), &profile); err != nil {
+		return profile, errors.Wrap(err, "m.Profiles.Save")
+	}
+
+	return profile, nil
+}
+
+// MovieReleases ranks the releases of a movie according to its quality profile
+func (m *Manager) MovieReleases(movie models.Movie) ([]ReleaseEvaluation, error) {
+	profile, err := m.Profile(movie.ProfileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "m.Profile")
+	}
+
+	evaluations := RankReleases(profile, movie)
+	for i := range evaluations {
+		nzb := movie.Release(evaluations[i].NzbID)
+		blocked, err := m.IsBlocklisted(nzb.GUID, nzb.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "m.IsBlocklisted")
+		}
+		if blocked {
+			evaluations[i].reject("release is blocklisted")
+		}
+	}
+	sortEvaluations(evaluations)
+
+	return evaluations, nil
+}
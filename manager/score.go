@@ -0,0 +1,148 @@
+package manager
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"pomegranate/models"
+)
+
+// ReleaseEvaluation is the result of checking a release against a quality profile
+type ReleaseEvaluation struct {
+	NzbID    string   `json:"nzb_id"`
+	Title    string   `json:"title"`
+	Accepted bool     `json:"accepted"`
+	Score    int      `json:"score"`
+	Reasons  []string `json:"reasons"`
+}
+
+func (e *ReleaseEvaluation) reject(format string, a ...interface{}) {
+	e.Accepted = false
+	e.Reasons = append(e.Reasons, "rejected: "+fmt.Sprintf(format, a...))
+}
+
+func (e *ReleaseEvaluation) accept(points int, format string, a ...interface{}) {
+	e.Score += points
+	e.Reasons = append(e.Reasons, fmt.Sprintf("%+d: ", points)+fmt.Sprintf(format, a...))
+}
+
+var separators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// normalizeTitle lowercases a release title and replaces every separator by a single space.
+// The result is padded with spaces so whole words can be matched with strings.Contains.
+func normalizeTitle(title string) string {
+	return " " + strings.TrimSpace(separators.ReplaceAllString(strings.ToLower(title), " ")) + " "
+}
+
+func containsWord(normalizedTitle string, word string) bool {
+	w := strings.TrimSpace(normalizeTitle(word))
+	if w == "" {
+		return false
+	}
+
+	return strings.Contains(normalizedTitle, " "+w+" ")
+}
+
+// preference returns how good a value is according to a list ordered by preference.
+// The first item of the list gets the highest value. ok is false if the value is not in the list.
+func preference(list []string, value string) (points int, ok bool) {
+	for i, item := range list {
+		if strings.EqualFold(item, value) {
+			return len(list) - i, true
+		}
+	}
+
+	return 0, false
+}
+
+func evaluateAttribute(e *ReleaseEvaluation, name string, allowed []string, value string, weight int) {
+	if len(allowed) == 0 {
+		return
+	}
+	if value == "" {
+		e.reject("unknown %s", name)
+		return
+	}
+
+	points, ok := preference(allowed, value)
+	if !ok {
+		e.reject("%s %s is not allowed", name, value)
+		return
+	}
+	e.accept(points*weight, "%s %s", name, value)
+}
+
+// EvaluateRelease checks a single release against a profile. runtime is the movie runtime in minutes
+// and is used for the size limits; size checks are skipped when it is unknown.
+func EvaluateRelease(profile models.QualityProfile, runtime int32, nzb models.NzbInfo) ReleaseEvaluation {
+	e := ReleaseEvaluation{
+		NzbID:    nzb.ID,
+		Title:    nzb.Title,
+		Accepted: true,
+	}
+
+	if nzb.Status == models.StatusFailed || nzb.Status == models.StatusError {
+		e.reject("release status is %s", nzb.Status)
+	}
+
+	info := nzb.ReleaseInfo()
+	evaluateAttribute(&e, "resolution", profile.Resolutions, info.Resolution, 100)
+	evaluateAttribute(&e, "source", profile.Sources, info.Source, 10)
+	evaluateAttribute(&e, "codec", profile.Codecs, info.VideoCodec, 1)
+
+	if runtime > 0 && nzb.Size > 0 {
+		sizePerMinute := float64(nzb.Size) / 1024 / 1024 / float64(runtime)
+		if profile.MinSizePerMinute > 0 && sizePerMinute < profile.MinSizePerMinute {
+			e.reject("%.1f MB/min is below the minimum of %.1f MB/min", sizePerMinute, profile.MinSizePerMinute)
+		}
+		if profile.MaxSizePerMinute > 0 && sizePerMinute > profile.MaxSizePerMinute {
+			e.reject("%.1f MB/min is above the maximum of %.1f MB/min", sizePerMinute, profile.MaxSizePerMinute)
+		}
+	}
+
+	title := normalizeTitle(nzb.Title)
+	for _, word := range profile.RejectedWords {
+		if containsWord(title, word) {
+			e.reject("contains rejected word %q", word)
+		}
+	}
+	for _, word := range profile.PreferredWords {
+		if containsWord(title, word) {
+			e.accept(5, "contains preferred word %q", word)
+		}
+	}
+
+	if info.Proper || info.Repack {
+		e.accept(2, "proper or repack release")
+	}
+
+	if e.Accepted && len(e.Reasons) == 0 {
+		e.Reasons = append(e.Reasons, "accepted: matches profile")
+	}
+
+	return e
+}
+
+// RankReleases evaluates every release of a movie. Accepted releases come first, best score first.
+func RankReleases(profile models.QualityProfile, movie models.Movie) []ReleaseEvaluation {
+	evaluations := make([]ReleaseEvaluation, 0, len(movie.NzbInfo))
+	for _, nzb := range movie.NzbInfo {
+		evaluations = append(evaluations, EvaluateRelease(profile, movie.Runtime, nzb))
+	}
+
+	sortEvaluations(evaluations)
+
+	return evaluations
+}
+
+// sortEvaluations puts accepted releases first, best score first
+func sortEvaluations(evaluations []ReleaseEvaluation) {
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		if evaluations[i].Accepted != evaluations[j].Accepted {
+			return evaluations[i].Accepted
+		}
+		return evaluations[i].Score > evaluations[j].Score
+	})
+}
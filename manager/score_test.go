@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"pomegranate/models"
+	"testing"
+)
+
+func TestEvaluateRelease(t *testing.T) {
+	profile := models.QualityProfile{
+		Resolutions:      []string{"1080p", "720p"},
+		Sources:          []string{"BluRay", "WEB-DL"},
+		MaxSizePerMinute: 100,
+		PreferredWords:   []string{"proper"},
+		RejectedWords:    []string{"hdcam"},
+	}
+
+	testCases := []struct {
+		title    string
+		size     int64
+		status   models.NzbStatus
+		accepted bool
+	}{
+		{"Movie.2019.1080p.BluRay.x264-GRP", 8 << 30, models.StatusUnknown, true},
+		{"Movie.2019.720p.WEB-DL.x264-GRP", 3 << 30, models.StatusUnknown, true},
+		{"Movie.2019.2160p.BluRay.x265-GRP", 8 << 30, models.StatusUnknown, false},
+		{"Movie.2019.1080p.HDTV.x264-GRP", 8 << 30, models.StatusUnknown, false},
+		{"Movie.2019.HDCAM.x264-GRP", 1 << 30, models.StatusUnknown, false},
+		{"Movie.2019.1080p.BluRay.x264-GRP", 80 << 30, models.StatusUnknown, false},
+		{"Movie.2019.1080p.BluRay.x264-GRP", 8 << 30, models.StatusFailed, false},
+	}
+
+	for _, testCase := range testCases {
+		nzb := models.NzbInfo{Title: testCase.title, Size: testCase.size, Status: testCase.status}
+		e := EvaluateRelease(profile, 120, nzb)
+		if e.Accepted != testCase.accepted {
+			t.Errorf("%s: expected accepted to be %t, got %t (%v)", testCase.title, testCase.accepted, e.Accepted, e.Reasons)
+		}
+	}
+}
+
+func TestRankReleases(t *testing.T) {
+	profile := models.QualityProfile{
+		Resolutions:    []string{"1080p", "720p"},
+		PreferredWords: []string{"proper"},
+	}
+	movie := models.Movie{
+		NzbInfo: []models.NzbInfo{
+			{ID: "rejected", Title: "Movie.2019.480p.DVDRip-GRP"},
+			{ID: "720p", Title: "Movie.2019.720p.BluRay.x264-GRP"},
+			{ID: "1080p", Title: "Movie.2019.1080p.BluRay.x264-GRP"},
+			{ID: "1080p-proper", Title: "Movie.2019.PROPER.1080p.BluRay.x264-GRP"},
+		},
+	}
+
+	expected := []string{"1080p-proper", "1080p", "720p", "rejected"}
+	ranked := RankReleases(profile, movie)
+	if len(ranked) != len(expected) {
+		t.Fatalf("expected %d evaluations, got %d", len(expected), len(ranked))
+	}
+	for i, id := range expected {
+		if ranked[i].NzbID != id {
+			t.Errorf("expected %s at position %d, got %s", id, i, ranked[i].NzbID)
+		}
+	}
+	if ranked[len(ranked)-1].Accepted {
+		t.Errorf("480p release should not be accepted")
+	}
+}
@@ -0,0 +1,367 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"pomegranate/release"
+
+	"github.com/lsmoura/humantoken"
+	"github.com/pkg/errors"
+)
+
+// FoundRelease is a search result, with every indexer it was found on
+type FoundRelease struct {
+	newznab.SearchResponseItem
+	Sources []string `json:"sources"` // Hosts of the indexers listing the release
+}
+
+// sameRelease reports whether two indexers list the same post. GUIDs and urls are specific to an
+// indexer, so posts are compared by title and size, allowing for the rounding of sizes.
+func sameRelease(title string, size int64, other newznab.SearchResponseItem) bool {
+	if !strings.EqualFold(title, other.Title) {
+		return false
+	}
+	if size == 0 || other.Size == 0 {
+		return true
+	}
+
+	diff := size - other.Size
+	if diff < 0 {
+		diff = -diff
+	}
+	largest := size
+	if other.Size > largest {
+		largest = other.Size
+	}
+
+	return diff*100 <= largest
+}
+
+// addSources appends the sources missing from the list, returning whether any was added
+func addSources(list *[]string, sources []string) bool {
+	added := false
+	for _, source := range sources {
+		found := false
+		for _, s := range *list {
+			if s == source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			*list = append(*list, source)
+			added = true
+		}
+	}
+
+	return added
+}
+
+// MergeReleases appends to the movie every search result it does not know yet, and records the new
+// sources of the releases it knows. It returns the number of releases added.
+func MergeReleases(movie *models.Movie, items []FoundRelease) int {
+	added := 0
+
+	for _, item := range items {
+		found := false
+		for i, nzb := range movie.NzbInfo {
+			if nzb.URL == item.URL || (item.GUID != "" && nzb.GUID == item.GUID) || sameRelease(nzb.Title, nzb.Size, item.SearchResponseItem) {
+				addSources(&movie.NzbInfo[i].Sources, item.Sources)
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		info := release.Parse(item.Title)
+		movie.NzbInfo = append(movie.NzbInfo, models.NzbInfo{
+			ID:      humantoken.Generate(8, nil),
+			Title:   item.Title,
+			GUID:    item.GUID,
+			URL:     item.URL,
+			Status:  models.StatusUnknown,
+			Size:    item.Size,
+			Sources: item.Sources,
+
+			Release: &info,
+		})
+		added++
+	}
+
+	return added
+}
+
+// DefaultIndexerTimeout is the time an indexer is given to answer all the searches of a movie
+const DefaultIndexerTimeout = 30 * time.Second
+
+// indexerSearch is the outcome of the searches of a movie on an indexer
+type indexerSearch struct {
+	items    []newznab.SearchResponseItem
+	err      error
+	skipped  bool
+	duration time.Duration
+}
+
+// findReleases queries every indexer for the given movie in parallel, leaving out blocklisted releases.
+// Each indexer gets IndexerTimeout to answer; the results it returned before failing are kept.
+// Indexers in backoff after repeated failures, or over their daily api limit, are skipped; an indexer
+// reaching its limit during the search is cut off, keeping the results it returned.
+// Releases found on several indexers are returned once, with all their sources. An error is returned
+// only when every queried indexer failed, skipped ones aside; the report has the outcome of each one.
+func (m *Manager) findReleases(ctx context.Context, movie models.Movie) ([]FoundRelease, models.SearchReport, error) {
+	report := models.SearchReport{SearchedAt: time.Now().UTC(), Indexers: []models.IndexerSearchResult{}}
+
+	imdbId := strings.TrimPrefix(movie.ImdbId, "tt")
+	if imdbId == "" {
+		return nil, report, errors.New("movie has no imdb id")
+	}
+
+	timeout := m.IndexerTimeout
+	if timeout <= 0 {
+		timeout = DefaultIndexerTimeout
+	}
+
+	searches := make([]indexerSearch, len(m.Indexers))
+	var wg sync.WaitGroup
+	for i, n := range m.Indexers {
+		wg.Add(1)
+		go func(i int, n newznab.Newznab) {
+			defer wg.Done()
+
+			status, err := m.indexerStatus(ctx, n.Host)
+			if err != nil {
+				searches[i] = indexerSearch{err: errors.Wrap(err, "m.indexerStatus")}
+				return
+			}
+			if !status.Available(time.Now()) {
+				err := errors.Errorf("backing off until %s after %d failures", status.NextRetryAt.Format(time.RFC3339), status.Failures)
+				searches[i] = indexerSearch{err: upstream(n.Host, err), skipped: true}
+				return
+			}
+			quota, err := m.indexerQuota(ctx, n)
+			if err != nil {
+				searches[i] = indexerSearch{err: errors.Wrap(err, "m.indexerQuota")}
+				return
+			}
+			if quota.apiExhausted() {
+				searches[i] = indexerSearch{err: upstream(n.Host, quota.apiError()), skipped: true}
+				return
+			}
+
+			indexerCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			items, searchErr := m.searchIndexer(indexerCtx, m.counted(n), movie, imdbId)
+			// a search cut off by the api limit, or interrupted by the caller, says nothing about the indexer
+			limited := errors.Is(searchErr, ErrQuotaExhausted)
+			if ctx.Err() == nil && !limited {
+				if err := m.recordIndexerResult(ctx, n, searchErr); err != nil {
+					log.Printf("cannot record the status of %s: %s\n", n.Host, err)
+				}
+			}
+
+			allowed, err := m.withoutBlocklisted(items)
+			if err != nil && searchErr == nil {
+				searchErr = errors.Wrap(err, "m.withoutBlocklisted")
+			}
+			searches[i] = indexerSearch{items: allowed, err: searchErr, skipped: limited, duration: time.Since(start)}
+		}(i, n)
+	}
+	wg.Wait()
+
+	var found []FoundRelease
+	var firstErr error
+	for i, search := range searches {
+		host := m.Indexers[i].Host
+		result := models.IndexerSearchResult{Host: host, Found: len(search.items), Skipped: search.skipped, Duration: search.duration.Milliseconds()}
+		if search.skipped {
+			result.Error = search.err.Error()
+		} else if search.err != nil {
+			log.Printf("search of %s on %s failed after %d results: %s\n", movie.ImdbId, host, len(search.items), search.err)
+			result.Error = search.err.Error()
+			if firstErr == nil {
+				firstErr = search.err
+			}
+		}
+		report.Indexers = append(report.Indexers, result)
+
+		for _, item := range search.items {
+			merged := false
+			for j := range found {
+				if sameRelease(found[j].Title, found[j].Size, item) {
+					addSources(&found[j].Sources, []string{host})
+					merged = true
+					break
+				}
+			}
+			if !merged {
+				found = append(found, FoundRelease{SearchResponseItem: item, Sources: []string{host}})
+			}
+		}
+	}
+
+	if report.Failed() {
+		return found, report, errors.Wrap(firstErr, "every indexer failed")
+	}
+
+	return found, report, nil
+}
+
+// maxTitleSearches bounds the number of text searches of a movie on an indexer: its title, then its
+// alternative titles
+const maxTitleSearches = 4
+
+// searchTitles returns the titles a movie is searched by, without duplicates once normalized
+func searchTitles(movie models.Movie) []string {
+	var titles []string
+	for _, title := range append([]string{movie.Title}, movie.AlternativeTitles...) {
+		if strings.TrimSpace(normalizeTitle(title)) == "" || containsTitle(titles, title) {
+			continue
+		}
+		titles = append(titles, title)
+		if len(titles) == maxTitleSearches {
+			break
+		}
+	}
+
+	return titles
+}
+
+// searchIndexer searches an indexer by imdb id, then by title and alternative titles, keeping the
+// results of text searches matching the movie. Each search runs only if the indexer supports it;
+// indexers whose capabilities are unknown are only searched by imdb id.
+// The results are deduplicated by GUID.
+func (m *Manager) searchIndexer(ctx context.Context, n newznab.Newznab, movie models.Movie, imdbId string) ([]newznab.SearchResponseItem, error) {
+	caps, err := m.IndexerCaps(ctx, n)
+	capsKnown := err == nil
+	if !capsKnown {
+		log.Printf("cannot read capabilities of %s: %s\n", n.Host, err)
+	}
+
+	var found []newznab.SearchResponseItem
+	seen := make(map[string]bool)
+	add := func(items []newznab.SearchResponseItem, textSearch bool) {
+		for _, item := range items {
+			if item.GUID != "" && seen[item.GUID] {
+				continue
+			}
+			if textSearch && !matchesMovie(movie, item.Title) {
+				continue
+			}
+			seen[item.GUID] = true
+			found = append(found, item)
+		}
+	}
+
+	imdbSearch := !capsKnown || caps.Supports(newznab.SearchModeMovie, "imdbid")
+	textSearch := capsKnown && caps.Supports(newznab.SearchModeSearch, "q")
+	if !imdbSearch && !textSearch {
+		log.Printf("%s supports neither imdb id nor text searches\n", n.Host)
+		return nil, nil
+	}
+
+	if imdbSearch {
+		items, err := n.SearchImdb(ctx, imdbId)
+		add(items, false)
+		if err != nil {
+			return found, errors.Wrap(upstream(n.Host, err), "newznab.SearchImdb")
+		}
+	}
+	if !textSearch {
+		return found, nil
+	}
+
+	categories := movieCategories(caps)
+	for _, title := range searchTitles(movie) {
+		query := title
+		if year := movie.Year(); year > 0 {
+			query = fmt.Sprintf("%s %d", query, year)
+		}
+		items, err := n.Search(ctx, newznab.SearchOptions{Query: query, Categories: categories})
+		add(items, true)
+		if err != nil {
+			return found, errors.Wrap(upstream(n.Host, err), "newznab.Search")
+		}
+	}
+
+	return found, nil
+}
+
+// SearchReleases queries every indexer for the given movie, merges the results into it and records
+// the outcome of the search on it. The movie is not saved.
+func (m *Manager) SearchReleases(ctx context.Context, movie *models.Movie) (int, error) {
+	items, report, err := m.findReleases(ctx, *movie)
+	added := MergeReleases(movie, items)
+	movie.LastSearch = &report
+	if err != nil {
+		return added, errors.Wrap(err, "m.findReleases")
+	}
+
+	return added, nil
+}
+
+// SearchWanted searches new releases for every movie that was not downloaded yet.
+// A failure on a single movie does not stop the others from being searched.
+func (m *Manager) SearchWanted(ctx context.Context) error {
+	movies, err := m.AllMovies()
+	if err != nil {
+		return errors.Wrap(err, "m.AllMovies")
+	}
+
+	failed := 0
+	for _, movie := range movies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if movie.Downloaded() {
+			continue
+		}
+
+		items, report, err := m.findReleases(ctx, movie)
+		if err != nil {
+			log.Printf("cannot search releases for %s: %s\n", movie.ImdbId, err)
+			failed++
+		}
+
+		// results are merged into the stored movie, as the search may take a while
+		added := 0
+		err = m.Movies.Update(ctx, movie.ImdbId, func(dst interface{}) error {
+			stored := dst.(*models.Movie)
+			added = MergeReleases(stored, items)
+			stored.LastSearch = &report
+			movie = *stored
+
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "m.Movies.Update (%s)", movie.ImdbId)
+		}
+		if added > 0 {
+			log.Printf("%d new releases found for %s\n", added, movie.ImdbId)
+			before := releasesSummary(len(movie.NzbInfo) - added)
+			m.recordHistory(models.EventReleasesFound, models.ActorSystem, movie, "", before, releasesSummary(len(movie.NzbInfo)))
+		}
+		m.publish(EventSearchCompleted, movie, "", fmt.Sprintf("%d new", added))
+
+		if _, err := m.grabBest(&movie, models.ActorSystem); err != nil {
+			log.Printf("cannot grab release for %s: %s\n", movie.ImdbId, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return errors.Errorf("search failed for %d of %d movies", failed, len(movies))
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIndexer answers imdb searches with the given releases, as title:size pairs
+func fakeIndexer(t *testing.T, name string, releases ...string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "movie" {
+			http.Error(w, "unsupported", http.StatusNotImplemented)
+			return
+		}
+
+		var items strings.Builder
+		for i, release := range releases {
+			parts := strings.SplitN(release, ":", 2)
+			fmt.Fprintf(&items, `<item><title>%s</title><guid>%s-%d</guid><enclosure url="http://%s/%d" length="1" type="application/x-nzb" />
+<newznab:attr name="size" value="%s" /></item>`, parts[0], name, i, name, i, parts[1])
+		}
+		_, _ = fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel><newznab:response offset="0" total="%d" />%s</channel></rss>`, len(releases), items.String())
+	}))
+}
+
+func TestManager_SearchReleasesConcurrently(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+	m.IndexerTimeout = 200 * time.Millisecond
+
+	first := fakeIndexer(t, "first", "The.Matrix.1999.1080p.BluRay.x264-GRP:8000000000")
+	defer first.Close()
+	second := fakeIndexer(t, "second", "The.Matrix.1999.1080p.BluRay.x264-GRP:8000001000", "The.Matrix.1999.720p.BluRay.x264-GRP:4000000000")
+	defer second.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	for _, server := range []*httptest.Server{first, second, failing, slow} {
+		m.Indexers = append(m.Indexers, newznab.Newznab{Host: strings.TrimPrefix(server.URL, "http://")})
+	}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix", ReleaseDate: "1999-03-30"}
+	start := time.Now()
+	added, err := m.SearchReleases(context.Background(), &movie)
+	if err != nil {
+		t.Fatalf("m.SearchReleases: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("slow indexer was not cut off: search took %s", elapsed)
+	}
+
+	if added != 2 {
+		t.Fatalf("expected 2 releases, got %d: %+v", added, movie.NzbInfo)
+	}
+	if sources := movie.NzbInfo[0].Sources; len(sources) != 2 || sources[0] != m.Indexers[0].Host || sources[1] != m.Indexers[1].Host {
+		t.Errorf("release found on both indexers should have both sources: %v", sources)
+	}
+	if sources := movie.NzbInfo[1].Sources; len(sources) != 1 || sources[0] != m.Indexers[1].Host {
+		t.Errorf("unexpected sources: %v", sources)
+	}
+
+	report := movie.LastSearch
+	if report == nil || len(report.Indexers) != 4 {
+		t.Fatalf("unexpected search report: %+v", report)
+	}
+	for i, expected := range []struct {
+		found  int
+		failed bool
+	}{{1, false}, {2, false}, {0, true}, {0, true}} {
+		result := report.Indexers[i]
+		if result.Host != m.Indexers[i].Host || result.Found != expected.found || (result.Error != "") != expected.failed {
+			t.Errorf("unexpected result for indexer %d: %+v", i, result)
+		}
+	}
+
+	// the search fails only when every indexer does; the failing ones are in backoff by now
+	m.Indexers = m.Indexers[2:]
+	for _, n := range m.Indexers {
+		if err := m.ResetIndexer(n.Host); err != nil {
+			t.Fatalf("m.ResetIndexer: %s", err)
+		}
+	}
+	if _, err := m.SearchReleases(context.Background(), &movie); err == nil {
+		t.Errorf("expected an error when every indexer fails")
+	}
+	if !movie.LastSearch.Failed() {
+		t.Errorf("search report should be failed: %+v", movie.LastSearch)
+	}
+}
+
+func TestManager_SearchTimeoutHidesApiKey(t *testing.T) {
+	m, cleanup := newTestManager(t)
+	defer cleanup()
+	m.IndexerTimeout = 100 * time.Millisecond
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer slow.Close()
+	m.Indexers = []newznab.Newznab{{Host: strings.TrimPrefix(slow.URL, "http://"), ApiKey: "SECRETKEY"}}
+
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	if _, err := m.SearchReleases(context.Background(), &movie); err == nil {
+		t.Fatalf("expected the search to time out")
+	}
+	if errText := movie.LastSearch.Indexers[0].Error; !strings.Contains(errText, "deadline exceeded") || strings.Contains(errText, "SECRETKEY") {
+		t.Errorf("unexpected error in search report: %s", errText)
+	}
+}
@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pomegranate"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests served, by route and status code.",
+	}, []string{"method", "route", "status"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time taken to serve HTTP requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_requests_total",
+		Help:      "Requests sent to external services, by status code, or error when no response was received.",
+	}, []string{"service", "host", "status"})
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "upstream_request_duration_seconds",
+		Help:      "Time taken by external services to respond.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"service", "host"})
+
+	// Grabs counts the releases sent to the downloader
+	Grabs = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grabs_total",
+		Help:      "Releases sent to the downloader.",
+	})
+	// DownloadFailures counts the downloads reported as failed by the downloader
+	DownloadFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "download_failures_total",
+		Help:      "Downloads reported as failed by the downloader.",
+	})
+
+	jobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of background job runs, by result.",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 30, 60, 300, 900, 1800},
+	}, []string{"job", "result"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the requests served by a chi router. Requests are labeled by route pattern, like
+// /api/v1/movies/{imdbId}, rather than by path, so the number of series stays bounded.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+type transport struct {
+	service string
+	next    http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	upstreamDuration.WithLabelValues(t.service, req.URL.Host).Observe(time.Since(start).Seconds())
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamRequests.WithLabelValues(t.service, req.URL.Host, status).Inc()
+
+	return resp, err
+}
+
+// Client returns an http client recording the latency and status codes of its requests, labeled with
+// the service name and the host of each request
+func Client(service string) *http.Client {
+	return &http.Client{Transport: transport{service: service, next: http.DefaultTransport}}
+}
+
+// ObserveJob records the duration of a background job run
+func ObserveJob(name string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	jobDuration.WithLabelValues(name, result).Observe(duration.Seconds())
+}
+
+type statusCollector struct {
+	desc   *prometheus.Desc
+	counts func() (map[string]int, error)
+}
+
+func (c statusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c statusCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.counts()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for status, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(count), status)
+	}
+}
+
+// RegisterMovieCounts exposes the number of movies by status, read from counts on every scrape
+func RegisterMovieCounts(counts func() (map[string]int, error)) error {
+	return prometheus.Register(statusCollector{
+		desc:   prometheus.NewDesc(namespace+"_movies", "Movies in the library, by status.", []string{"status"}, nil),
+		counts: counts,
+	})
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func scrape(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Get("/movies/{imdbId}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/movies/tt0133093", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nothing", nil))
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+	resp, err := Client("newznab").Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Client.Get: %s", err)
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	err = RegisterMovieCounts(func() (map[string]int, error) {
+		return map[string]int{"wanted": 2, "downloaded": 1}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterMovieCounts: %s", err)
+	}
+
+	host := strings.TrimPrefix(upstream.URL, "http://")
+	body := scrape(t)
+	for _, expected := range []string{
+		`pomegranate_http_requests_total{method="GET",route="/movies/{imdbId}",status="418"} 1`,
+		`pomegranate_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`pomegranate_upstream_requests_total{host="` + host + `",service="newznab",status="503"} 1`,
+		`pomegranate_upstream_request_duration_seconds_count{host="` + host + `",service="newznab"} 1`,
+		`pomegranate_movies{status="wanted"} 2`,
+		`pomegranate_movies{status="downloaded"} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("missing %s", expected)
+		}
+	}
+}
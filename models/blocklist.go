@@ -0,0 +1,45 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"pomegranate/database"
+	"time"
+)
+
+const BlocklistKind = "blocklist"
+
+// BlocklistEntry is a release that must not be grabbed again, keyed by the nzb GUID
+type BlocklistEntry struct {
+	GUID      string    `json:"guid"`
+	Title     string    `json:"title"`
+	ImdbId    string    `json:"imdb_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (b *BlocklistEntry) Kind() string {
+	return BlocklistKind
+}
+
+func (b *BlocklistEntry) SetKey(key database.Key) {
+	b.GUID = string(key)
+}
+
+func (b *BlocklistEntry) GetKey() database.Key {
+	return []byte(b.GUID)
+}
+
+// Store saves the current blocklist entry to the database
+func (b BlocklistEntry) Store(db *database.DB) error {
+	dbBytes, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err := db.Store(BlocklistKind, b.GetKey(), dbBytes); err != nil {
+		return fmt.Errorf("DB.Store: %w", err)
+	}
+
+	return nil
+}
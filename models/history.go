@@ -0,0 +1,73 @@
+package models
+
+import (
+	"pomegranate/database"
+	"time"
+)
+
+const HistoryKind = "history"
+
+// HistoryImdbIndex maps the imdb id of a movie, followed by a slash and the id of the entry, to each entry
+// of the movie, so its history is read in order with a prefix
+const HistoryImdbIndex = "imdb_id"
+
+type HistoryEvent string
+
+const (
+	EventMovieAdded        HistoryEvent = "movie_added"
+	EventReleasesFound     HistoryEvent = "releases_found"
+	EventReleaseGrabbed    HistoryEvent = "release_grabbed"
+	EventDownloadCompleted HistoryEvent = "download_completed"
+	EventDownloadFailed    HistoryEvent = "download_failed"
+	EventMovieImported     HistoryEvent = "movie_imported"
+	EventMovieDeleted      HistoryEvent = "movie_deleted"
+)
+
+// Actors of history events: a user request, or a background job
+const (
+	ActorUser   = "user"
+	ActorSystem = "system"
+)
+
+// HistoryEntry is an event of the life of a movie. Entries are only ever appended, and their ids sort
+// chronologically.
+type HistoryEntry struct {
+	ID        string       `json:"id"`
+	Event     HistoryEvent `json:"event"`
+	ImdbId    string       `json:"imdb_id"`
+	Title     string       `json:"title"`
+	NzbID     string       `json:"nzb_id,omitempty"`
+	Actor     string       `json:"actor"`
+	Before    string       `json:"before,omitempty"` // Summary of the state before the event
+	After     string       `json:"after,omitempty"`  // Summary of the state after the event
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+func (h *HistoryEntry) Kind() string {
+	return HistoryKind
+}
+
+func (h *HistoryEntry) SetKey(key database.Key) {
+	h.ID = string(key)
+}
+
+func (h *HistoryEntry) GetKey() database.Key {
+	return []byte(h.ID)
+}
+
+func (h *HistoryEntry) Indexes() map[string]database.IndexFunc {
+	return map[string]database.IndexFunc{
+		HistoryImdbIndex: func(record database.Model) []string {
+			entry, ok := record.(*HistoryEntry)
+			if !ok {
+				return nil
+			}
+			return []string{HistoryImdbPrefix(entry.ImdbId) + entry.ID}
+		},
+	}
+}
+
+// HistoryImdbPrefix is the prefix of the HistoryImdbIndex values of the entries of a movie
+func HistoryImdbPrefix(imdbId string) string {
+	return imdbId + "/"
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"pomegranate/database"
+	"pomegranate/newznab"
+	"time"
+)
+
+const IndexerCapsKind = "indexer_caps"
+
+// IndexerCaps caches the capabilities of an indexer, keyed by its host
+type IndexerCaps struct {
+	Host      string       `json:"host"`
+	Caps      newznab.Caps `json:"caps"`
+	FetchedAt time.Time    `json:"fetched_at"`
+}
+
+func (c *IndexerCaps) Kind() string {
+	return IndexerCapsKind
+}
+
+func (c *IndexerCaps) SetKey(key database.Key) {
+	c.Host = string(key)
+}
+
+func (c *IndexerCaps) GetKey() database.Key {
+	return []byte(c.Host)
+}
+
+const IndexerStatusKind = "indexer_status"
+
+// IndexerStatus tracks the failures of an indexer, keyed by its host. An indexer failing repeatedly is
+// left alone until NextRetryAt.
+type IndexerStatus struct {
+	Host          string    `json:"host"`
+	Failures      int       `json:"failures"` // Consecutive failures
+	LastError     string    `json:"last_error,omitempty"`
+	ErrorCode     int       `json:"error_code,omitempty"` // Newznab error code of the last failure, if any
+	LastFailureAt time.Time `json:"last_failure_at"`
+	LastSuccessAt time.Time `json:"last_success_at"`
+	NextRetryAt   time.Time `json:"next_retry_at"`
+}
+
+func (s *IndexerStatus) Kind() string {
+	return IndexerStatusKind
+}
+
+func (s *IndexerStatus) SetKey(key database.Key) {
+	s.Host = string(key)
+}
+
+func (s *IndexerStatus) GetKey() database.Key {
+	return []byte(s.Host)
+}
+
+// Available reports whether the indexer may be queried at the given time
+func (s IndexerStatus) Available(now time.Time) bool {
+	return !now.Before(s.NextRetryAt)
+}
+
+const IndexerUsageKind = "indexer_usage"
+
+// IndexerUsage counts the api hits and grabs of an indexer during a day (UTC), keyed by its host.
+// The limits are the ones reported by the indexer, if any.
+type IndexerUsage struct {
+	Host      string    `json:"host"`
+	Day       string    `json:"day"` // YYYY-MM-DD
+	ApiHits   int       `json:"api_hits"`
+	Grabs     int       `json:"grabs"`
+	ApiMax    int       `json:"api_max,omitempty"`
+	GrabMax   int       `json:"grab_max,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (u *IndexerUsage) Kind() string {
+	return IndexerUsageKind
+}
+
+func (u *IndexerUsage) SetKey(key database.Key) {
+	u.Host = string(key)
+}
+
+func (u *IndexerUsage) GetKey() database.Key {
+	return []byte(u.Host)
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"pomegranate/database"
+	"pomegranate/release"
+	"strconv"
+	"time"
 )
 
 const MovieBucketName = "movies"
@@ -17,9 +20,17 @@ const (
 	StatusSuccess             = "success"
 	StatusUnknown             = "unknown"
 	StatusError               = "error"
+	StatusDeleted             = "deleted" // Removed by hand from the downloader: neither blocklisted nor replaced
 )
 
-const MovieKind = "movie"
+const MovieKind = MovieBucketName
+
+// Secondary indexes of the movies bucket, mapping a release to the movie holding it
+const (
+	NzbIDIndex        = "nzb_id"
+	NzbGUIDIndex      = "nzb_guid"
+	DownloaderIDIndex = "downloader_id"
+)
 
 type NzbInfo struct {
 	GUID   string    `json:"guid"`
@@ -29,15 +40,79 @@ type NzbInfo struct {
 	Title  string    `json:"title"`
 	URL    string    `json:"url"`
 
-	DownloaderId string `json:"downloader_id"`
+	Sources []string `json:"sources,omitempty"` // Hosts of the indexers listing the release
+
+	DownloaderId string  `json:"downloader_id"`
+	Progress     float64 `json:"progress"`               // Download progress, from 0 to 100
+	StoragePath  string  `json:"storage_path,omitempty"` // Where the downloader stored the completed job
+	FailMessage  string  `json:"fail_message,omitempty"` // Reason reported by the downloader for a failed job
+	ImportError  string  `json:"import_error,omitempty"` // Why the completed download is not in the library yet
+
+	Release *release.Info `json:"release,omitempty"`
+}
+
+// ReleaseInfo returns the metadata parsed from the release title
+func (n NzbInfo) ReleaseInfo() release.Info {
+	if n.Release != nil {
+		return *n.Release
+	}
+
+	return release.Parse(n.Title)
+}
+
+// MovieFile is the movie file imported into the library
+type MovieFile struct {
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	Quality    string    `json:"quality"`
+	NzbID      string    `json:"nzb_id,omitempty"` // Release the file was imported from
+	ImportedAt time.Time `json:"imported_at"`
 }
 
 type Movie struct {
-	ImdbId      string    `json:"imdb_id"`
-	Title       string    `json:"title"`
-	Overview    string    `json:"overview"`
-	ReleaseDate string    `json:"release_date"`
-	NzbInfo     []NzbInfo `json:"nzb_info"`
+	ImdbId            string     `json:"imdb_id"`
+	Title             string     `json:"title"`
+	AlternativeTitles []string   `json:"alternative_titles,omitempty"` // Other titles of the movie on themoviedb, searched on indexers
+	Overview          string     `json:"overview"`
+	ReleaseDate       string     `json:"release_date"`
+	Runtime           int32      `json:"runtime"` // in minutes
+	ProfileID         string     `json:"profile_id"`
+	NzbInfo           []NzbInfo  `json:"nzb_info"`
+	File              *MovieFile `json:"file,omitempty"`
+
+	LastSearch *SearchReport `json:"last_search,omitempty"`
+}
+
+// IndexerSearchResult is the outcome of a release search on a single indexer
+type IndexerSearchResult struct {
+	Host     string `json:"host"`
+	Found    int    `json:"found"` // Releases returned by the indexer, including the ones found on other indexers too
+	Error    string `json:"error,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"` // Not queried, or cut off, while in backoff or over its api limit; Error tells why
+	Duration int64  `json:"duration_ms"`
+}
+
+// SearchReport describes the last release search of a movie, indexer by indexer
+type SearchReport struct {
+	SearchedAt time.Time             `json:"searched_at"`
+	Indexers   []IndexerSearchResult `json:"indexers"`
+}
+
+// Failed reports whether the search failed on every indexer it queried. Skipped indexers do not count:
+// a search that skipped every indexer did not fail.
+func (r SearchReport) Failed() bool {
+	queried := 0
+	for _, indexer := range r.Indexers {
+		if indexer.Skipped {
+			continue
+		}
+		if indexer.Error == "" {
+			return false
+		}
+		queried++
+	}
+
+	return queried > 0
 }
 
 func (m *Movie) Kind() string {
@@ -52,6 +127,91 @@ func (m *Movie) GetKey() database.Key {
 	return []byte(m.ImdbId)
 }
 
+// releaseValues collects a value of every release of a movie
+func releaseValues(record database.Model, value func(NzbInfo) string) []string {
+	m, ok := record.(*Movie)
+	if !ok {
+		return nil
+	}
+
+	var values []string
+	for _, info := range m.NzbInfo {
+		values = append(values, value(info))
+	}
+
+	return values
+}
+
+func (m *Movie) Indexes() map[string]database.IndexFunc {
+	return map[string]database.IndexFunc{
+		NzbIDIndex: func(record database.Model) []string {
+			return releaseValues(record, func(n NzbInfo) string { return n.ID })
+		},
+		NzbGUIDIndex: func(record database.Model) []string {
+			return releaseValues(record, func(n NzbInfo) string { return n.GUID })
+		},
+		DownloaderIDIndex: func(record database.Model) []string {
+			return releaseValues(record, func(n NzbInfo) string { return n.DownloaderId })
+		},
+	}
+}
+
+// Year returns the release year of the movie, or 0 if its release date is unknown
+func (m Movie) Year() int {
+	if len(m.ReleaseDate) < 4 {
+		return 0
+	}
+
+	year, err := strconv.Atoi(m.ReleaseDate[:4])
+	if err != nil {
+		return 0
+	}
+
+	return year
+}
+
+// Downloaded reports whether the movie is already on disk, either because a release was successfully
+// downloaded or because its file was found in the library. A download that could not be imported does
+// not count.
+func (m Movie) Downloaded() bool {
+	if m.File != nil {
+		return true
+	}
+	for _, info := range m.NzbInfo {
+		if info.Status == StatusSuccess && info.ImportError == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Grabbed reports whether a release was already sent to the downloader, or the movie is already in the library.
+// A release removed by hand from the downloader counts, so another one is not grabbed in its place.
+func (m Movie) Grabbed() bool {
+	if m.File != nil {
+		return true
+	}
+	for _, info := range m.NzbInfo {
+		if info.Status == StatusSnatched || info.Status == StatusSuccess || info.Status == StatusDeleted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Release returns the release with the given id, or nil if the movie has no such release
+func (m *Movie) Release(id string) *NzbInfo {
+	for i := range m.NzbInfo {
+		if m.NzbInfo[i].ID == id {
+			return &m.NzbInfo[i]
+		}
+	}
+
+	return nil
+}
+
 // Store saves the current movie data to the database
 func (m Movie) Store(db *database.DB) error {
 	dbBytes, err := json.Marshal(m)
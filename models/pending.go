@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"pomegranate/database"
+	"time"
+)
+
+const PendingImportKind = "pending_imports"
+
+// MatchCandidate is a themoviedb movie that may correspond to a file found on disk
+type MatchCandidate struct {
+	TmdbId      int32  `json:"tmdb_id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date"`
+}
+
+// PendingImport is a file found by the library scanner that could not be matched to a single movie
+// and waits for a manual confirmation
+type PendingImport struct {
+	ID          string           `json:"id"`
+	Path        string           `json:"path"`
+	Size        int64            `json:"size"`
+	Quality     string           `json:"quality"`
+	ParsedTitle string           `json:"parsed_title"`
+	ParsedYear  int              `json:"parsed_year"`
+	Candidates  []MatchCandidate `json:"candidates"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
+
+func (p *PendingImport) Kind() string {
+	return PendingImportKind
+}
+
+func (p *PendingImport) SetKey(key database.Key) {
+	p.ID = string(key)
+}
+
+func (p *PendingImport) GetKey() database.Key {
+	return []byte(p.ID)
+}
+
+// Store saves the current pending import to the database
+func (p PendingImport) Store(db *database.DB) error {
+	dbBytes, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err := db.Store(PendingImportKind, p.GetKey(), dbBytes); err != nil {
+		return fmt.Errorf("DB.Store: %w", err)
+	}
+
+	return nil
+}
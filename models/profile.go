@@ -0,0 +1,65 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"pomegranate/database"
+)
+
+const ProfileKind = "profiles"
+
+// DefaultProfileID is the profile used by movies that do not reference any profile
+const DefaultProfileID = "default"
+
+// QualityProfile describes which releases are acceptable for a movie and which ones are preferred.
+// Resolutions, Sources and Codecs are ordered by preference, the first entry being the best one.
+// An empty list accepts any value.
+type QualityProfile struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Resolutions []string `json:"resolutions"`
+	Sources     []string `json:"sources"`
+	Codecs      []string `json:"codecs"`
+
+	// Size limits, in megabytes per minute of runtime. Zero means no limit.
+	MinSizePerMinute float64 `json:"min_size_per_minute"`
+	MaxSizePerMinute float64 `json:"max_size_per_minute"`
+
+	PreferredWords []string `json:"preferred_words"`
+	RejectedWords  []string `json:"rejected_words"`
+}
+
+// DefaultProfile is created on startup when no profile exists
+var DefaultProfile = QualityProfile{
+	ID:               DefaultProfileID,
+	Name:             "Default",
+	Resolutions:      []string{"1080p", "720p"},
+	MaxSizePerMinute: 200,
+	RejectedWords:    []string{"cam", "hdcam", "telesync", "hdts", "telecine", "workprint"},
+}
+
+func (p *QualityProfile) Kind() string {
+	return ProfileKind
+}
+
+func (p *QualityProfile) SetKey(key database.Key) {
+	p.ID = string(key)
+}
+
+func (p *QualityProfile) GetKey() database.Key {
+	return []byte(p.ID)
+}
+
+// Store saves the current profile data to the database
+func (p QualityProfile) Store(db *database.DB) error {
+	dbBytes, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("json.Marshal: %w", err)
+	}
+
+	if err := db.Store(ProfileKind, p.GetKey(), dbBytes); err != nil {
+		return fmt.Errorf("DB.Store: %w", err)
+	}
+
+	return nil
+}
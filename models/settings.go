@@ -0,0 +1,6 @@
+package models
+
+// SettingsKind is the bucket holding single values, like the api key, stored under fixed keys
+const SettingsKind = "settings"
+
+const ApiKeySetting = "api_key"
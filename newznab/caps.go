@@ -0,0 +1,205 @@
+package newznab
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Search modes of the caps searching element
+const (
+	SearchModeSearch = "search"
+	SearchModeMovie  = "movie-search"
+)
+
+// Caps are the capabilities an indexer advertises with t=caps
+type Caps struct {
+	Title         string                      `json:"title,omitempty"`
+	Version       string                      `json:"version,omitempty"`
+	MaxLimit      int                         `json:"max_limit,omitempty"`     // Maximum number of results per request
+	DefaultLimit  int                         `json:"default_limit,omitempty"` // Number of results when no limit is given
+	RetentionDays int                         `json:"retention_days,omitempty"`
+	Searching     map[string]SearchCapability `json:"searching"` // By search mode, like movie-search
+	Categories    []Category                  `json:"categories"`
+}
+
+type SearchCapability struct {
+	Available       bool     `json:"available"`
+	SupportedParams []string `json:"supported_params"`
+}
+
+type Category struct {
+	ID            int        `json:"id"`
+	Name          string     `json:"name"`
+	Subcategories []Category `json:"subcategories,omitempty"`
+}
+
+// Supports reports whether a search mode is available with the given parameter
+func (c Caps) Supports(mode string, param string) bool {
+	capability, ok := c.Searching[mode]
+	if !ok || !capability.Available {
+		return false
+	}
+	for _, p := range capability.SupportedParams {
+		if p == param {
+			return true
+		}
+	}
+
+	return false
+}
+
+type capsCategoryXML struct {
+	ID      int               `xml:"id,attr"`
+	Name    string            `xml:"name,attr"`
+	Subcats []capsCategoryXML `xml:"subcat"`
+}
+
+type capsXML struct {
+	XMLName xml.Name
+	Server  struct {
+		Version string `xml:"version,attr"`
+		Title   string `xml:"title,attr"`
+	} `xml:"server"`
+	Limits struct {
+		Max     int `xml:"max,attr"`
+		Default int `xml:"default,attr"`
+	} `xml:"limits"`
+	Retention struct {
+		Days int `xml:"days,attr"`
+	} `xml:"retention"`
+	Searching struct {
+		Modes []struct {
+			XMLName         xml.Name
+			Available       string `xml:"available,attr"`
+			SupportedParams string `xml:"supportedParams,attr"`
+		} `xml:",any"`
+	} `xml:"searching"`
+	Categories []capsCategoryXML `xml:"categories>category"`
+
+	// attributes of the error element
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+func convertCategories(list []capsCategoryXML) []Category {
+	var categories []Category
+	for _, c := range list {
+		categories = append(categories, Category{ID: c.ID, Name: c.Name, Subcategories: convertCategories(c.Subcats)})
+	}
+
+	return categories
+}
+
+// ParseCaps reads the response of a t=caps request
+func ParseCaps(r io.Reader) (Caps, error) {
+	var raw capsXML
+	if err := xml.NewDecoder(r).Decode(&raw); err != nil {
+		return Caps{}, fmt.Errorf("xml.Decode: %w", err)
+	}
+	if raw.XMLName.Local == "error" {
+		return Caps{}, Error{Code: raw.Code, Description: raw.Description}
+	}
+	if raw.XMLName.Local != "caps" {
+		return Caps{}, fmt.Errorf("unexpected root element %s", raw.XMLName.Local)
+	}
+
+	caps := Caps{
+		Title:         raw.Server.Title,
+		Version:       raw.Server.Version,
+		MaxLimit:      raw.Limits.Max,
+		DefaultLimit:  raw.Limits.Default,
+		RetentionDays: raw.Retention.Days,
+		Searching:     make(map[string]SearchCapability),
+		Categories:    convertCategories(raw.Categories),
+	}
+	for _, mode := range raw.Searching.Modes {
+		capability := SearchCapability{Available: mode.Available == "yes"}
+		for _, param := range strings.Split(mode.SupportedParams, ",") {
+			if param = strings.TrimSpace(param); param != "" {
+				capability.SupportedParams = append(capability.SupportedParams, param)
+			}
+		}
+		caps.Searching[mode.XMLName.Local] = capability
+	}
+
+	return caps, nil
+}
+
+func (n Newznab) httpClient() *http.Client {
+	if n.Client == nil {
+		return http.DefaultClient
+	}
+
+	return n.Client
+}
+
+// apiURL builds the url of an api call of the given type
+func (n Newznab) apiURL(t string, params url.Values) string {
+	query := url.Values{}
+	for key, values := range params {
+		query[key] = values
+	}
+	query.Set("t", t)
+	if n.ApiKey != "" {
+		query.Set("apikey", n.ApiKey)
+	}
+
+	return fmt.Sprintf("http://%s/api?%s", n.Host, query.Encode())
+}
+
+// redactError hides the api key in the url of a failed request, as timeouts and connection errors quote it
+func (n Newznab) redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = n.redact(urlErr.URL)
+	}
+
+	return err
+}
+
+// get runs an api call of the given type and returns the response body
+func (n Newznab) get(ctx context.Context, t string, params url.Values) ([]byte, error) {
+	if n.BeforeRequest != nil {
+		if err := n.BeforeRequest(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	u := n.apiURL(t, params)
+	fmt.Printf("HTTP request: %s\n", n.redact(u))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("http.NewRequestWithContext: %w", n.redactError(err))
+	}
+	resp, err := n.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("client.Do: %w", n.redactError(err))
+	}
+	defer resp.Body.Close()
+
+	return readResponse(resp)
+}
+
+// Caps queries the capabilities of the indexer
+func (n Newznab) Caps(ctx context.Context) (Caps, error) {
+	body, err := n.get(ctx, "caps", nil)
+	if err != nil {
+		return Caps{}, err
+	}
+	n.observe(nil)
+
+	caps, err := ParseCaps(bytes.NewReader(body))
+	if err != nil {
+		return Caps{}, fmt.Errorf("ParseCaps: %w", err)
+	}
+
+	return caps, nil
+}
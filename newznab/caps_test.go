@@ -0,0 +1,71 @@
+package newznab
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const capsResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<caps>
+  <server version="1.0" title="Indexer" />
+  <limits max="100" default="50" />
+  <retention days="3000" />
+  <searching>
+    <search available="yes" supportedParams="q" />
+    <tv-search available="yes" supportedParams="q,rid,season,ep" />
+    <movie-search available="yes" supportedParams="q, imdbid" />
+    <audio-search available="no" supportedParams="" />
+  </searching>
+  <categories>
+    <category id="2000" name="Movies">
+      <subcat id="2040" name="HD" />
+      <subcat id="2045" name="UHD" />
+    </category>
+    <category id="5000" name="TV" />
+  </categories>
+</caps>`
+
+func TestNewznab_Caps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("t") != "caps" || r.URL.Query().Get("apikey") != "key" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		_, _ = w.Write([]byte(capsResponse))
+	}))
+	defer server.Close()
+
+	n := Newznab{Host: strings.TrimPrefix(server.URL, "http://"), ApiKey: "key"}
+	caps, err := n.Caps(context.Background())
+	if err != nil {
+		t.Fatalf("n.Caps: %s", err)
+	}
+
+	if caps.Title != "Indexer" || caps.MaxLimit != 100 || caps.DefaultLimit != 50 || caps.RetentionDays != 3000 {
+		t.Errorf("unexpected caps: %+v", caps)
+	}
+	if !caps.Supports(SearchModeMovie, "imdbid") || !caps.Supports(SearchModeSearch, "q") {
+		t.Errorf("imdbid and text searches should be supported: %+v", caps.Searching)
+	}
+	if caps.Supports(SearchModeMovie, "tmdbid") || caps.Supports("audio-search", "q") {
+		t.Errorf("unsupported searches reported as supported: %+v", caps.Searching)
+	}
+	if len(caps.Categories) != 2 || len(caps.Categories[0].Subcategories) != 2 || caps.Categories[0].Subcategories[1].ID != 2045 {
+		t.Errorf("unexpected categories: %+v", caps.Categories)
+	}
+}
+
+func TestParseCaps_Error(t *testing.T) {
+	_, err := ParseCaps(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Incorrect user credentials"/>`))
+
+	var newznabErr Error
+	if !errors.As(err, &newznabErr) {
+		t.Fatalf("expected a newznab error, got %v", err)
+	}
+	if newznabErr.Code != 100 || newznabErr.Description != "Incorrect user credentials" {
+		t.Errorf("unexpected error: %+v", newznabErr)
+	}
+}
@@ -0,0 +1,100 @@
+package newznab
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// Error codes of the newznab api. Limit errors are also reported with the 429 http status.
+const (
+	CodeIncorrectCredentials   = 100
+	CodeAccountSuspended       = 101
+	CodeInsufficientPrivileges = 102
+	CodeRegistrationDenied     = 103
+	CodeMissingParameter       = 200
+	CodeIncorrectParameter     = 201
+	CodeNoSuchFunction         = 202
+	CodeFunctionNotAvailable   = 203
+	CodeNoSuchItem             = 300
+	CodeTooManyRequests        = http.StatusTooManyRequests
+	CodeRequestLimitReached    = 500
+	CodeDownloadLimitReached   = 501
+	CodeUnknownError           = 900
+	CodeApiDisabled            = 910
+)
+
+// Error is an error reported by the indexer in place of a response
+type Error struct {
+	Code        int
+	Description string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("newznab error %d: %s", e.Code, e.Description)
+}
+
+// LimitReached reports whether the account went over its api or download limit
+func (e Error) LimitReached() bool {
+	return e.Code == CodeTooManyRequests || e.Code == CodeRequestLimitReached || e.Code == CodeDownloadLimitReached
+}
+
+// AccountError reports whether the account cannot use the api at all, until it is fixed on the indexer
+func (e Error) AccountError() bool {
+	switch e.Code {
+	case CodeIncorrectCredentials, CodeAccountSuspended, CodeInsufficientPrivileges, CodeRegistrationDenied, CodeApiDisabled:
+		return true
+	}
+
+	return false
+}
+
+// parseError returns the error of a response whose root is an error element, or nil
+func parseError(data []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			// not xml, or no element at all: not an error response
+			return nil
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "error" {
+			return nil
+		}
+
+		var e struct {
+			Code        int    `xml:"code,attr"`
+			Description string `xml:"description,attr"`
+		}
+		if err := decoder.DecodeElement(&e, &start); err != nil {
+			return fmt.Errorf("decoder.DecodeElement: %w", err)
+		}
+		return Error{Code: e.Code, Description: e.Description}
+	}
+}
+
+// readResponse reads the body of an api response, turning error elements and error statuses into errors
+func readResponse(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadAll: %w", err)
+	}
+	if err := parseError(body); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, Error{Code: CodeTooManyRequests, Description: http.StatusText(resp.StatusCode)}
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return body, nil
+}
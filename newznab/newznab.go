@@ -1,15 +1,47 @@
 package newznab
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/mmcdole/gofeed"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Newznab struct {
 	ApiKey string
 	Host   string
+	Client *http.Client // http.DefaultClient when nil
+
+	// Daily limits of the account, zero to rely on the limits the indexer reports
+	ApiLimit  int
+	GrabLimit int
+
+	// BeforeRequest is called before every api call, which is not made when it returns an error
+	BeforeRequest func(ctx context.Context) error
+
+	// OnResponse is called after every successful api call, with the limits reported by the indexer,
+	// or nil when it reports none
+	OnResponse func(limits *Limits)
+}
+
+// Limits are the usage counters of the account, as reported in the newznab:apilimits element
+type Limits struct {
+	ApiCurrent  int
+	ApiMax      int
+	GrabCurrent int
+	GrabMax     int
+}
+
+// observe reports a successful api call to OnResponse
+func (n Newznab) observe(limits *Limits) {
+	if n.OnResponse != nil {
+		n.OnResponse(limits)
+	}
 }
 
 type SearchResponseItem struct {
@@ -17,6 +49,8 @@ type SearchResponseItem struct {
 	Title string `json:"title"`
 	URL   string `json:"url"`
 	Size  int64  `json:"size"`
+
+	PublishedAt time.Time `json:"published_at"`
 }
 
 func parseAttrs(item *gofeed.Item) (map[string]string, error) {
@@ -54,22 +88,130 @@ func parseAttrs(item *gofeed.Item) (map[string]string, error) {
 	return items, nil
 }
 
-func (n Newznab) SearchImdb(imdbId string) ([]SearchResponseItem, error) {
-	url := fmt.Sprintf("http://%s/api?imdbid=%s&apikey=%s&t=movie&extended=1", n.Host, imdbId, n.ApiKey)
+// redact hides the api key in a url before it is logged
+func (n Newznab) redact(u string) string {
+	if n.ApiKey == "" {
+		return u
+	}
+
+	return strings.ReplaceAll(u, n.ApiKey, "xxx")
+}
+
+// Newznab category ids of movies. Indexers list their own subcategories in their capabilities.
+const (
+	CategoryMovies    = 2000
+	CategoryMoviesSD  = 2030
+	CategoryMoviesHD  = 2040
+	CategoryMoviesUHD = 2045
+)
+
+// defaultMaxPages bounds the number of requests of a single search
+const defaultMaxPages = 10
+
+// SearchOptions describe a search. Searches with an imdb id use t=movie; the others use t=search.
+type SearchOptions struct {
+	Query      string // Free text
+	ImdbId     string // Without the tt prefix
+	Categories []int
+	MaxAge     int   // In days, zero for no limit
+	MinSize    int64 // In bytes, zero for no limit
+	MaxSize    int64 // In bytes, zero for no limit
+	Limit      int   // Results per page, the indexer default when zero
+	MaxPages   int   // defaultMaxPages when zero
+}
+
+func (o SearchOptions) params() (string, url.Values) {
+	t := "search"
+	params := url.Values{"extended": {"1"}}
+	if o.ImdbId != "" {
+		t = "movie"
+		params.Set("imdbid", o.ImdbId)
+	}
+	if o.Query != "" {
+		params.Set("q", o.Query)
+	}
+	if len(o.Categories) > 0 {
+		var categories []string
+		for _, category := range o.Categories {
+			categories = append(categories, strconv.Itoa(category))
+		}
+		params.Set("cat", strings.Join(categories, ","))
+	}
+	if o.MaxAge > 0 {
+		params.Set("maxage", strconv.Itoa(o.MaxAge))
+	}
+	if o.Limit > 0 {
+		params.Set("limit", strconv.Itoa(o.Limit))
+	}
+
+	return t, params
+}
 
-	fp := gofeed.NewParser()
-	fmt.Printf("HTTP request: %s\n", strings.ReplaceAll(url, n.ApiKey, "xxx"))
-	feed, err := fp.ParseURL(url)
+// accepts filters the items indexers return despite the options, or that no parameter can exclude
+func (o SearchOptions) accepts(item SearchResponseItem) bool {
+	if o.MinSize > 0 && item.Size > 0 && item.Size < o.MinSize {
+		return false
+	}
+	if o.MaxSize > 0 && item.Size > o.MaxSize {
+		return false
+	}
+	if o.MaxAge > 0 && !item.PublishedAt.IsZero() && time.Since(item.PublishedAt) > time.Duration(o.MaxAge)*24*time.Hour {
+		return false
+	}
+
+	return true
+}
+
+// responseTotal reads the total number of results from the newznab:response element, or -1 if the
+// indexer does not report it
+func responseTotal(feed *gofeed.Feed) int {
+	for _, extension := range feed.Extensions["newznab"]["response"] {
+		if total, err := strconv.Atoi(extension.Attrs["total"]); err == nil {
+			return total
+		}
+	}
+
+	return -1
+}
+
+// responseLimits reads the newznab:apilimits element, or returns nil if the indexer does not report it
+func responseLimits(feed *gofeed.Feed) *Limits {
+	for _, extension := range feed.Extensions["newznab"]["apilimits"] {
+		attr := func(name string) int {
+			value, _ := strconv.Atoi(extension.Attrs[name])
+			return value
+		}
+
+		return &Limits{
+			ApiCurrent:  attr("apicurrent"),
+			ApiMax:      attr("apimax"),
+			GrabCurrent: attr("grabcurrent"),
+			GrabMax:     attr("grabmax"),
+		}
+	}
+
+	return nil
+}
+
+// searchPage runs an api call returning an rss feed of releases, and the total number of results
+func (n Newznab) searchPage(ctx context.Context, t string, params url.Values) ([]SearchResponseItem, int, error) {
+	body, err := n.get(ctx, t, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	feed, err := gofeed.NewParser().Parse(bytes.NewReader(body))
 	if err != nil {
-		return nil, fmt.Errorf("fp.ParseURL: %w", err)
+		return nil, 0, fmt.Errorf("parser.Parse: %w", err)
 	}
+	n.observe(responseLimits(feed))
 
 	var itemList []SearchResponseItem
 
 	for _, item := range feed.Items {
 		attrs, err := parseAttrs(item)
 		if err != nil {
-			return nil, fmt.Errorf("parseAttrs: %w", err)
+			return nil, 0, fmt.Errorf("parseAttrs: %w", err)
 		}
 		searchItem := SearchResponseItem{Title: item.Title, GUID: item.GUID}
 		if attrs["size"] != "" {
@@ -83,9 +225,65 @@ func (n Newznab) SearchImdb(imdbId string) ([]SearchResponseItem, error) {
 		if len(item.Enclosures) > 0 {
 			searchItem.URL = item.Enclosures[0].URL
 		}
+		if item.PublishedParsed != nil {
+			searchItem.PublishedAt = item.PublishedParsed.UTC()
+		}
 
 		itemList = append(itemList, searchItem)
 	}
 
-	return itemList, nil
+	return itemList, responseTotal(feed), nil
+}
+
+// Search queries the indexer page by page, until every result was read or MaxPages is reached.
+// The results read before an error, or before ctx is done, are returned with it.
+func (n Newznab) Search(ctx context.Context, options SearchOptions) ([]SearchResponseItem, error) {
+	maxPages := options.MaxPages
+	if maxPages <= 0 {
+		maxPages = defaultMaxPages
+	}
+	t, params := options.params()
+
+	var found []SearchResponseItem
+	seen := make(map[string]bool)
+	offset := 0
+	for page := 0; page < maxPages; page++ {
+		if offset > 0 {
+			params.Set("offset", strconv.Itoa(offset))
+		}
+		items, total, err := n.searchPage(ctx, t, params)
+		if err != nil {
+			return found, err
+		}
+
+		// indexers ignoring the offset return the same page again
+		fresh := 0
+		for _, item := range items {
+			key := item.GUID
+			if key == "" {
+				key = item.URL
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			fresh++
+
+			if options.accepts(item) {
+				found = append(found, item)
+			}
+		}
+
+		offset += len(items)
+		if fresh == 0 || (total >= 0 && offset >= total) || (options.Limit > 0 && len(items) < options.Limit) {
+			break
+		}
+	}
+
+	return found, nil
+}
+
+// SearchImdb searches movies by imdb id, without the tt prefix
+func (n Newznab) SearchImdb(ctx context.Context, imdbId string) ([]SearchResponseItem, error) {
+	return n.Search(ctx, SearchOptions{ImdbId: imdbId})
 }
@@ -0,0 +1,130 @@
+package newznab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// searchPage renders a page of results, release i being i+1 megabytes large and i days old
+func searchPage(offset int, count int, total int) string {
+	var items strings.Builder
+	for i := offset; i < offset+count; i++ {
+		published := time.Now().Add(-time.Duration(i) * 24 * time.Hour).Format(time.RFC1123Z)
+		fmt.Fprintf(&items, `<item><title>Release.%d</title><guid>guid-%d</guid><pubDate>%s</pubDate>
+<enclosure url="http://indexer/%d" length="1" type="application/x-nzb" />
+<newznab:attr name="size" value="%d" /></item>`, i, i, published, i, (i+1)*1000000)
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:newznab="http://www.newznab.com/DTD/2010/feeds/attributes/">
+<channel><newznab:response offset="%d" total="%d" />%s</channel></rss>`, offset, total, items.String())
+}
+
+func TestNewznab_Search(t *testing.T) {
+	const total = 25
+
+	var offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("t") != "search" || query.Get("q") != "heat 1995" || query.Get("cat") != "2040,2045" || query.Get("maxage") != "20" {
+			t.Errorf("unexpected request: %s", r.URL)
+		}
+		offsets = append(offsets, query.Get("offset"))
+
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		count := total - offset
+		if count > 10 {
+			count = 10
+		}
+		_, _ = w.Write([]byte(searchPage(offset, count, total)))
+	}))
+	defer server.Close()
+
+	n := Newznab{Host: strings.TrimPrefix(server.URL, "http://")}
+	items, err := n.Search(context.Background(), SearchOptions{
+		Query:      "heat 1995",
+		Categories: []int{CategoryMoviesHD, CategoryMoviesUHD},
+		MaxAge:     20,
+		MinSize:    2000000,
+		MaxSize:    22000000,
+	})
+	if err != nil {
+		t.Fatalf("n.Search: %s", err)
+	}
+
+	if strings.Join(offsets, ",") != ",10,20" {
+		t.Errorf("unexpected pages requested: %v", offsets)
+	}
+	// releases 1 to 19 are within the size limits and younger than 20 days
+	if len(items) != 19 || items[0].GUID != "guid-1" || items[len(items)-1].GUID != "guid-19" {
+		t.Errorf("unexpected results: %+v", items)
+	}
+}
+
+func TestNewznab_SearchIgnoredOffset(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(strings.Replace(searchPage(0, 5, 0), `<newznab:response offset="0" total="0" />`, "", 1)))
+	}))
+	defer server.Close()
+
+	n := Newznab{Host: strings.TrimPrefix(server.URL, "http://")}
+	items, err := n.SearchImdb(context.Background(), "0113277")
+	if err != nil {
+		t.Fatalf("n.SearchImdb: %s", err)
+	}
+
+	if len(items) != 5 || requests != 2 {
+		t.Errorf("expected 5 results in 2 requests, got %d in %d", len(items), requests)
+	}
+}
+
+func TestNewznab_SearchError(t *testing.T) {
+	testCases := []struct {
+		status       int
+		body         string
+		code         int
+		limitReached bool
+		accountError bool
+	}{
+		{status: http.StatusOK, body: `<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Incorrect user credentials"/>`, code: CodeIncorrectCredentials, accountError: true},
+		{status: http.StatusOK, body: `<error code="500" description="Request limit reached"/>`, code: CodeRequestLimitReached, limitReached: true},
+		{status: http.StatusTooManyRequests, body: `slow down`, code: CodeTooManyRequests, limitReached: true},
+		{status: http.StatusForbidden, body: `<error code="910" description="API disabled"/>`, code: CodeApiDisabled, accountError: true},
+		{status: http.StatusBadGateway, body: `bad gateway`},
+	}
+
+	for _, tc := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			_, _ = w.Write([]byte(tc.body))
+		}))
+
+		n := Newznab{Host: strings.TrimPrefix(server.URL, "http://")}
+		_, err := n.SearchImdb(context.Background(), "0113277")
+		server.Close()
+
+		if err == nil {
+			t.Errorf("%s: expected an error", tc.body)
+			continue
+		}
+		var newznabErr Error
+		if !errors.As(err, &newznabErr) {
+			if tc.code != 0 {
+				t.Errorf("%s: expected a newznab error, got %v", tc.body, err)
+			}
+			continue
+		}
+		if newznabErr.Code != tc.code || newznabErr.LimitReached() != tc.limitReached || newznabErr.AccountError() != tc.accountError {
+			t.Errorf("%s: unexpected error %+v", tc.body, newznabErr)
+		}
+	}
+}
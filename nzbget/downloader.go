@@ -0,0 +1,181 @@
+package nzbget
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"pomegranate/downloader"
+
+	"github.com/pkg/errors"
+)
+
+// NzbGet implements downloader.Downloader
+var _ downloader.Downloader = NzbGet{}
+
+func (n NzbGet) Name() string {
+	return "nzbget"
+}
+
+func (n NzbGet) AddByURL(url string, name string) (string, error) {
+	if !strings.HasSuffix(strings.ToLower(name), ".nzb") && name != "" {
+		name += ".nzb"
+	}
+
+	id, err := n.Append(AppendParams{NZBFilename: name, Content: url})
+	if err != nil {
+		return "", errors.Wrap(err, "n.Append")
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (n NzbGet) AddByContent(filename string, content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", errors.New("content is required for adding a file")
+	}
+
+	id, err := n.Append(AppendParams{NZBFilename: filename, Content: base64.StdEncoding.EncodeToString(content)})
+	if err != nil {
+		return "", errors.Wrap(err, "n.Append")
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+// filterIds returns a function reporting whether a job id was requested. Every id is accepted if none is given.
+func filterIds(ids []string) func(id int64) bool {
+	wanted := make(map[string]bool)
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	return func(id int64) bool {
+		return len(wanted) == 0 || wanted[strconv.FormatInt(id, 10)]
+	}
+}
+
+func groupJob(group Group) downloader.Job {
+	job := downloader.Job{
+		ID:   strconv.FormatInt(group.NZBID, 10),
+		Name: group.NZBName,
+	}
+
+	switch group.Status {
+	case "QUEUED", "FETCHING":
+		job.Status = downloader.JobQueued
+	case "PAUSED":
+		job.Status = downloader.JobPaused
+	case "DOWNLOADING":
+		job.Status = downloader.JobDownloading
+	default:
+		// PP_QUEUED, LOADING_PARS, VERIFYING_SOURCES, REPAIRING, UNPACKING, MOVING, EXECUTING_SCRIPT...
+		job.Status = downloader.JobProcessing
+	}
+
+	if group.FileSizeMB > 0 {
+		job.Progress = float64(group.FileSizeMB-group.RemainingSizeMB) / float64(group.FileSizeMB) * 100
+	}
+
+	return job
+}
+
+func historyJob(item HistoryItem) downloader.Job {
+	job := downloader.Job{
+		ID:       strconv.FormatInt(item.NZBID, 10),
+		Name:     item.Name,
+		Progress: 100,
+	}
+
+	switch {
+	case strings.HasPrefix(item.Status, "SUCCESS"), item.Status == "WARNING/SCRIPT":
+		job.Status = downloader.JobCompleted
+		job.StoragePath = item.FinalDir
+		if job.StoragePath == "" {
+			job.StoragePath = item.DestDir
+		}
+	case item.Status == "DELETED/MANUAL":
+		job.Status = downloader.JobDeleted
+	default:
+		job.Status = downloader.JobFailed
+		job.FailMessage = item.Status
+	}
+
+	return job
+}
+
+func (n NzbGet) QueueJobs(ids ...string) ([]downloader.Job, error) {
+	groups, err := n.ListGroups()
+	if err != nil {
+		return nil, errors.Wrap(err, "n.ListGroups")
+	}
+
+	wanted := filterIds(ids)
+	var jobs []downloader.Job
+	for _, group := range groups {
+		if wanted(group.NZBID) {
+			jobs = append(jobs, groupJob(group))
+		}
+	}
+
+	return jobs, nil
+}
+
+func (n NzbGet) HistoryJobs(ids ...string) ([]downloader.Job, error) {
+	items, err := n.History(false)
+	if err != nil {
+		return nil, errors.Wrap(err, "n.History")
+	}
+
+	wanted := filterIds(ids)
+	var jobs []downloader.Job
+	for _, item := range items {
+		if item.Kind != "" && item.Kind != "NZB" {
+			continue
+		}
+		if wanted(item.NZBID) {
+			jobs = append(jobs, historyJob(item))
+		}
+	}
+
+	return jobs, nil
+}
+
+func (n NzbGet) Pause() error {
+	return n.PauseDownload()
+}
+
+func (n NzbGet) Resume() error {
+	return n.ResumeDownload()
+}
+
+// Delete removes a job from the queue or the history. Jobs are searched in the queue first.
+func (n NzbGet) Delete(id string, deleteFiles bool) error {
+	nzbId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid job id %s", id)
+	}
+
+	groups, err := n.ListGroups()
+	if err != nil {
+		return errors.Wrap(err, "n.ListGroups")
+	}
+	for _, group := range groups {
+		if group.NZBID != nzbId {
+			continue
+		}
+
+		command := "GroupDelete"
+		if deleteFiles {
+			command = "GroupFinalDelete"
+		}
+		return n.EditQueue(command, "", nzbId)
+	}
+
+	command := "HistoryDelete"
+	if deleteFiles {
+		command = "HistoryFinalDelete"
+	}
+
+	return n.EditQueue(command, "", nzbId)
+}
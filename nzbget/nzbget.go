@@ -0,0 +1,260 @@
+package nzbget
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+type Logger interface {
+	Log(serviceName string, format string, a ...interface{})
+}
+
+// NzbGet is a client for the NZBGet JSON-RPC api
+type NzbGet struct {
+	Host     string
+	Username string
+	Password string
+	Logger   Logger
+	Client   *http.Client // http.DefaultClient when nil
+}
+
+func New(host string, username string, password string) NzbGet {
+	return NzbGet{
+		Host:     host,
+		Username: username,
+		Password: password,
+	}
+}
+
+func (n NzbGet) httpClient() *http.Client {
+	if n.Client == nil {
+		return http.DefaultClient
+	}
+
+	return n.Client
+}
+
+func (n NzbGet) log(format string, a ...interface{}) {
+	if n.Logger == nil {
+		return
+	}
+
+	n.Logger.Log("nzbget", format, a...)
+}
+
+func (n NzbGet) url() *url.URL {
+	// TODO: detect if scheme is present on Host
+	u := new(url.URL)
+
+	u.Scheme = "http"
+	u.Host = n.Host
+	u.Path = "jsonrpc"
+	if n.Username != "" {
+		u.User = url.UserPassword(n.Username, n.Password)
+	}
+
+	return u
+}
+
+type rpcRequest struct {
+	Version string        `json:"version"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+	ID      int           `json:"id"`
+}
+
+type RpcError struct {
+	Name    string `json:"name"`
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e RpcError) Error() string {
+	return fmt.Sprintf("%s (%d): %s", e.Name, e.Code, e.Message)
+}
+
+type rpcResponse struct {
+	Version string          `json:"version"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RpcError       `json:"error"`
+}
+
+// call invokes a remote method, decoding its result into dst
+func (n NzbGet) call(method string, params []interface{}, dst interface{}) error {
+	if n.Host == "" {
+		return errors.New("nzbget structure has no host")
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	payload, err := json.Marshal(rpcRequest{Version: "1.1", Method: method, Params: params, ID: 1})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	u := n.url()
+	n.log("HTTP post: %s %s\n", u.Redacted(), method)
+	resp, err := n.httpClient().Post(u.String(), "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "http.Post")
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Println(fmt.Errorf("Body.Close: %w", err))
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "ioutil.ReadAll")
+	}
+
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(body, &rpcResp); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+	if rpcResp.Error != nil {
+		return errors.Wrap(*rpcResp.Error, method)
+	}
+
+	if dst == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, dst); err != nil {
+		return errors.Wrap(err, "json.Unmarshal result")
+	}
+
+	return nil
+}
+
+// Group is a job in the download queue, as returned by listgroups
+type Group struct {
+	NZBID            int64  `json:"NZBID"`
+	NZBName          string `json:"NZBName"`
+	NZBFilename      string `json:"NZBFilename"`
+	Category         string `json:"Category"`
+	Status           string `json:"Status"`
+	FileSizeMB       int64  `json:"FileSizeMB"`
+	RemainingSizeMB  int64  `json:"RemainingSizeMB"`
+	DownloadedSizeMB int64  `json:"DownloadedSizeMB"`
+	PausedSizeMB     int64  `json:"PausedSizeMB"`
+	DestDir          string `json:"DestDir"`
+	FinalDir         string `json:"FinalDir"`
+	MaxPriority      int    `json:"MaxPriority"`
+	ActiveDownloads  int    `json:"ActiveDownloads"`
+}
+
+// HistoryItem is a finished job, as returned by history
+type HistoryItem struct {
+	NZBID       int64  `json:"NZBID"`
+	Kind        string `json:"Kind"`
+	Name        string `json:"Name"`
+	NZBFilename string `json:"NZBFilename"`
+	Category    string `json:"Category"`
+	Status      string `json:"Status"` // Like SUCCESS/ALL, FAILURE/PAR or DELETED/MANUAL
+	FileSizeMB  int64  `json:"FileSizeMB"`
+	DestDir     string `json:"DestDir"`
+	FinalDir    string `json:"FinalDir"`
+	HistoryTime int64  `json:"HistoryTime"`
+}
+
+type AppendParams struct {
+	NZBFilename string
+	Content     string // Either a url or the base64 encoded nzb file
+	Category    string
+	Priority    int
+	AddToTop    bool
+	AddPaused   bool
+	DupeKey     string
+	DupeScore   int
+	DupeMode    string
+}
+
+// Append adds a new job to the queue, returning its id
+func (n NzbGet) Append(params AppendParams) (int64, error) {
+	dupeMode := params.DupeMode
+	if dupeMode == "" {
+		dupeMode = "SCORE"
+	}
+
+	rpcParams := []interface{}{
+		params.NZBFilename,
+		params.Content,
+		params.Category,
+		params.Priority,
+		params.AddToTop,
+		params.AddPaused,
+		params.DupeKey,
+		params.DupeScore,
+		dupeMode,
+		[]interface{}{}, // post-processing parameters
+	}
+
+	var id int64
+	if err := n.call("append", rpcParams, &id); err != nil {
+		return 0, errors.Wrap(err, "n.call")
+	}
+	if id <= 0 {
+		return 0, errors.Errorf("append failed with id %d", id)
+	}
+
+	return id, nil
+}
+
+func (n NzbGet) ListGroups() ([]Group, error) {
+	var groups []Group
+	if err := n.call("listgroups", []interface{}{0}, &groups); err != nil {
+		return nil, errors.Wrap(err, "n.call")
+	}
+
+	return groups, nil
+}
+
+func (n NzbGet) History(hidden bool) ([]HistoryItem, error) {
+	var items []HistoryItem
+	if err := n.call("history", []interface{}{hidden}, &items); err != nil {
+		return nil, errors.Wrap(err, "n.call")
+	}
+
+	return items, nil
+}
+
+func (n NzbGet) boolCall(method string, params []interface{}) error {
+	var ok bool
+	if err := n.call(method, params, &ok); err != nil {
+		return errors.Wrap(err, "n.call")
+	}
+	if !ok {
+		return errors.Errorf("%s returned false", method)
+	}
+
+	return nil
+}
+
+func (n NzbGet) PauseDownload() error {
+	return n.boolCall("pausedownload", nil)
+}
+
+func (n NzbGet) ResumeDownload() error {
+	return n.boolCall("resumedownload", nil)
+}
+
+// EditQueue runs an edit command (like GroupFinalDelete or HistoryDelete) on the given jobs
+func (n NzbGet) EditQueue(command string, param string, ids ...int64) error {
+	return n.boolCall("editqueue", []interface{}{command, param, ids})
+}
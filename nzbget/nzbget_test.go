@@ -0,0 +1,136 @@
+package nzbget
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pomegranate/downloader"
+)
+
+// fakeNzbGet answers json-rpc calls with the result registered for each method
+func fakeNzbGet(t *testing.T, results map[string]string, calls *[]rpcRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/jsonrpc" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "nzbget" || pass != "tegbzn6789" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req rpcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("json.Decode: %s", err)
+		}
+		*calls = append(*calls, req)
+
+		result, ok := results[req.Method]
+		if !ok {
+			_, _ = w.Write([]byte(`{"version": "1.1", "error": {"name": "JSONRPCError", "code": 1, "message": "Invalid procedure"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"version": "1.1", "result": ` + result + `}`))
+	}))
+}
+
+func TestNzbGet_Downloader(t *testing.T) {
+	var calls []rpcRequest
+	server := fakeNzbGet(t, map[string]string{
+		"append": `42`,
+		"listgroups": `[
+			{"NZBID": 42, "NZBName": "The.Matrix.1999.1080p.BluRay.x264-GRP", "Status": "DOWNLOADING", "FileSizeMB": 1000, "RemainingSizeMB": 250},
+			{"NZBID": 43, "NZBName": "Other", "Status": "PAUSED", "FileSizeMB": 1000, "RemainingSizeMB": 1000}
+		]`,
+		"history": `[
+			{"NZBID": 40, "Kind": "NZB", "Name": "Completed", "Status": "SUCCESS/ALL", "DestDir": "/intermediate/Completed", "FinalDir": "/complete/Completed"},
+			{"NZBID": 41, "Kind": "NZB", "Name": "Failed", "Status": "FAILURE/PAR"},
+			{"NZBID": 39, "Kind": "URL", "Name": "Url", "Status": "FAILURE/FETCH"}
+		]`,
+		"editqueue": `true`,
+	}, &calls)
+	defer server.Close()
+
+	var d downloader.Downloader = New(strings.TrimPrefix(server.URL, "http://"), "nzbget", "tegbzn6789")
+
+	id, err := d.AddByURL("http://indexer/nzb", "The.Matrix.1999.1080p.BluRay.x264-GRP")
+	if err != nil {
+		t.Fatalf("d.AddByURL: %s", err)
+	}
+	if id != "42" {
+		t.Errorf("unexpected id: %s", id)
+	}
+	if params := calls[0].Params; params[0] != "The.Matrix.1999.1080p.BluRay.x264-GRP.nzb" || params[1] != "http://indexer/nzb" {
+		t.Errorf("unexpected append params: %v", params)
+	}
+
+	queue, err := d.QueueJobs("42")
+	if err != nil {
+		t.Fatalf("d.QueueJobs: %s", err)
+	}
+	if len(queue) != 1 || queue[0].Status != downloader.JobDownloading || queue[0].Progress != 75 {
+		t.Errorf("unexpected queue: %+v", queue)
+	}
+
+	history, err := d.HistoryJobs()
+	if err != nil {
+		t.Fatalf("d.HistoryJobs: %s", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 history jobs, got %d", len(history))
+	}
+	if job := history[0]; job.Status != downloader.JobCompleted || job.StoragePath != "/complete/Completed" {
+		t.Errorf("unexpected completed job: %+v", job)
+	}
+	if job := history[1]; job.Status != downloader.JobFailed || job.FailMessage != "FAILURE/PAR" {
+		t.Errorf("unexpected failed job: %+v", job)
+	}
+
+	if err := d.Delete("41", true); err != nil {
+		t.Fatalf("d.Delete: %s", err)
+	}
+	last := calls[len(calls)-1]
+	if last.Method != "editqueue" || last.Params[0] != "HistoryFinalDelete" {
+		t.Errorf("unexpected delete call: %+v", last)
+	}
+
+	if err := d.Pause(); err == nil {
+		t.Errorf("d.Pause should return the rpc error")
+	}
+}
+
+func TestNzbGet_Unauthorized(t *testing.T) {
+	var calls []rpcRequest
+	server := fakeNzbGet(t, map[string]string{}, &calls)
+	defer server.Close()
+
+	n := New(strings.TrimPrefix(server.URL, "http://"), "nzbget", "wrong")
+	if _, err := n.ListGroups(); err == nil {
+		t.Fatalf("n.ListGroups should fail with invalid credentials")
+	}
+}
+
+func TestHistoryJob(t *testing.T) {
+	testCases := []struct {
+		status      string
+		expected    downloader.JobStatus
+		failMessage string
+	}{
+		{status: "SUCCESS/ALL", expected: downloader.JobCompleted},
+		{status: "SUCCESS/HIDDEN", expected: downloader.JobCompleted},
+		{status: "WARNING/SCRIPT", expected: downloader.JobCompleted},
+		{status: "DELETED/MANUAL", expected: downloader.JobDeleted},
+		{status: "DELETED/DUPE", expected: downloader.JobFailed, failMessage: "DELETED/DUPE"},
+		{status: "FAILURE/PAR", expected: downloader.JobFailed, failMessage: "FAILURE/PAR"},
+		{status: "WARNING/DAMAGED", expected: downloader.JobFailed, failMessage: "WARNING/DAMAGED"},
+	}
+
+	for _, tc := range testCases {
+		job := historyJob(HistoryItem{NZBID: 1, Status: tc.status})
+		if job.Status != tc.expected || job.FailMessage != tc.failMessage {
+			t.Errorf("%s: unexpected job %+v", tc.status, job)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package release
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Info is the metadata extracted from a scene release name.
+// Fields that cannot be detected are left empty.
+type Info struct {
+	Title         string   `json:"title"`
+	Year          int      `json:"year,omitempty"`
+	Resolution    string   `json:"resolution,omitempty"`
+	Source        string   `json:"source,omitempty"`
+	VideoCodec    string   `json:"video_codec,omitempty"`
+	AudioCodec    string   `json:"audio_codec,omitempty"`
+	AudioChannels string   `json:"audio_channels,omitempty"`
+	HDR           []string `json:"hdr,omitempty"`
+	Edition       string   `json:"edition,omitempty"`
+	Languages     []string `json:"languages,omitempty"`
+	Proper        bool     `json:"proper,omitempty"`
+	Repack        bool     `json:"repack,omitempty"`
+	Group         string   `json:"group,omitempty"`
+}
+
+// Quality returns a short human readable description of the release quality, like "1080p BluRay"
+func (i Info) Quality() string {
+	var pieces []string
+	if i.Resolution != "" {
+		pieces = append(pieces, i.Resolution)
+	}
+	if i.Source != "" {
+		pieces = append(pieces, i.Source)
+	}
+
+	return strings.Join(pieces, " ")
+}
+
+// pattern maps a regular expression, matched against whole words of the normalized release name, to a value
+type pattern struct {
+	re    *regexp.Regexp
+	value string
+}
+
+// patterns builds a list of patterns from pairs of expressions and values
+func patterns(pairs ...string) []pattern {
+	var list []pattern
+	for i := 0; i+1 < len(pairs); i += 2 {
+		list = append(list, pattern{
+			re:    regexp.MustCompile(`(?:^| )(?:` + pairs[i] + `)(?: |$)`),
+			value: pairs[i+1],
+		})
+	}
+
+	return list
+}
+
+// All lists are ordered by priority: the first pattern that matches wins.
+var (
+	resolutionPatterns = patterns(
+		`2160p|4k|uhd`, "2160p",
+		`1080p|1080i|fhd`, "1080p",
+		`720p`, "720p",
+		`576p`, "576p",
+		`480p`, "480p",
+	)
+	sourcePatterns = patterns(
+		`remux|bdremux`, "Remux",
+		`blu ray|bluray|bdrip|brrip|bd25|bd50|bd`, "BluRay",
+		`webrip|web rip`, "WEBRip",
+		`web dl|webdl|web`, "WEB-DL",
+		`hdtv|pdtv|hdtvrip|dsr|tvrip`, "HDTV",
+		`dvdscr|screener|scr`, "SCREENER",
+		`dvdrip|dvdr|dvd5|dvd9|dvd`, "DVD",
+		`hdcam|cam|camrip`, "CAM",
+		`hdts|telesync|ts`, "TELESYNC",
+		`telecine|tc`, "TELECINE",
+		`workprint|wp`, "WORKPRINT",
+	)
+	videoCodecPatterns = patterns(
+		`x265|h265|h 265|hevc`, "x265",
+		`x264|h264|h 264|avc`, "x264",
+		`av1`, "AV1",
+		`vc 1|vc1`, "VC-1",
+		`xvid`, "XviD",
+		`divx`, "DivX",
+		`mpeg2|mpeg 2`, "MPEG-2",
+	)
+	audioCodecPatterns = patterns(
+		`truehd[0-9]?`, "TrueHD",
+		`dts x|dtsx`, "DTS-X",
+		`dts hd ma[0-9]?|dts hdma[0-9]?|dts ma[0-9]?`, "DTS-HD MA",
+		`dts hd[0-9]?|dtshd[0-9]?`, "DTS-HD",
+		`dts[0-9]?`, "DTS",
+		`ddp[0-9]?|dd\+[0-9]?|e ac3|eac3`, "DD+",
+		`dd[0-9]|ac3|dd`, "DD",
+		`aac[0-9]?`, "AAC",
+		`flac[0-9]?`, "FLAC",
+		`opus`, "Opus",
+		`mp3`, "MP3",
+	)
+	hdrPatterns = patterns(
+		`hdr10\+|hdr10plus|hdr10p`, "HDR10+",
+		`hdr10`, "HDR10",
+		`hdr`, "HDR",
+		`dv|dovi|dolby vision`, "DV",
+		`hlg`, "HLG",
+	)
+	editionPatterns = patterns(
+		`director s cut|directors cut|dc`, "Director's Cut",
+		`extended cut|extended edition|extended`, "Extended",
+		`theatrical cut|theatrical`, "Theatrical",
+		`unrated`, "Unrated",
+		`uncut`, "Uncut",
+		`final cut`, "Final Cut",
+		`special edition`, "Special Edition",
+		`ultimate edition|ultimate cut`, "Ultimate Edition",
+		`collector s edition|collectors edition`, "Collector's Edition",
+		`criterion`, "Criterion",
+		`remastered|remaster`, "Remastered",
+		`imax`, "IMAX",
+		`open matte`, "Open Matte",
+	)
+	languagePatterns = patterns(
+		`multi|multisubs`, "Multi",
+		`dual audio|dual`, "Dual Audio",
+		`truefrench|french|vff|vfq|vf2|vostfr`, "French",
+		`german|ger`, "German",
+		`italian|ita`, "Italian",
+		`spanish|castellano|esp|latino`, "Spanish",
+		`portuguese|por`, "Portuguese",
+		`nordic`, "Nordic",
+		`swedish|swe`, "Swedish",
+		`danish|dan`, "Danish",
+		`norwegian|nor`, "Norwegian",
+		`finnish|fin`, "Finnish",
+		`dutch|flemish`, "Dutch",
+		`polish|pl|plsub`, "Polish",
+		`russian|rus`, "Russian",
+		`japanese|jpn|jap`, "Japanese",
+		`korean|kor`, "Korean",
+		`chinese|chs|cht`, "Chinese",
+		`hindi`, "Hindi",
+		`hebrew|heb`, "Hebrew",
+		`turkish|tur`, "Turkish",
+	)
+	properPattern   = patterns(`proper|real proper`, "proper")[0]
+	repackPattern   = patterns(`repack|rerip`, "repack")[0]
+	channelsPattern = regexp.MustCompile(`(?:[a-z+]|^| )([1-9]) ([0-2])(?: |$)`)
+
+	// markers are the single words that may mark the end of the title when the release has no year
+	markerPatterns = [][]pattern{resolutionPatterns, sourcePatterns, videoCodecPatterns, editionPatterns, {properPattern, repackPattern}}
+
+	tokenSeparators = regexp.MustCompile(`[\s._\[\]()]+`)
+	wordSeparators  = regexp.MustCompile(`[^a-z0-9+]+`)
+	yearPattern     = regexp.MustCompile(`^(19|20)[0-9]{2}$`)
+	trailingTags    = regexp.MustCompile(`\s*\[[^\]]*\]$`)
+	groupPattern    = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+)
+
+var extensions = []string{".mkv", ".mp4", ".avi", ".m4v", ".wmv", ".mov", ".iso", ".nzb"}
+
+// notGroups are words that follow an hyphen in well-known release tags and must not be taken as release groups
+var notGroups = map[string]bool{
+	"dl": true, "hd": true, "x": true, "1": true, "ray": true, "ac3": true, "rip": true,
+}
+
+// normalize lowercases a string and replaces everything but letters, digits and '+' by a single space
+func normalize(s string) string {
+	return strings.TrimSpace(wordSeparators.ReplaceAllString(strings.ToLower(s), " "))
+}
+
+func match(list []pattern, s string) (string, int) {
+	for _, p := range list {
+		if loc := p.re.FindStringIndex(s); loc != nil {
+			return p.value, loc[0]
+		}
+	}
+
+	return "", -1
+}
+
+// matchAll returns the value of every pattern found in s, in order of appearance
+func matchAll(list []pattern, s string) []string {
+	type found struct {
+		value string
+		pos   int
+	}
+	var all []found
+	seen := make(map[string]bool)
+	for _, p := range list {
+		if loc := p.re.FindStringIndex(s); loc != nil && !seen[p.value] {
+			seen[p.value] = true
+			all = append(all, found{p.value, loc[0]})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].pos < all[j].pos })
+
+	var values []string
+	for _, f := range all {
+		values = append(values, f.value)
+	}
+
+	return values
+}
+
+func isMarker(token string) bool {
+	word := normalize(token)
+	if word == "" {
+		return false
+	}
+	for _, list := range markerPatterns {
+		for _, p := range list {
+			if p.re.MatchString(word) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// splitGroup removes the release group from the end of the name, returning both
+func splitGroup(name string) (string, string) {
+	i := strings.LastIndex(name, "-")
+	if i < 0 {
+		return name, ""
+	}
+
+	group := strings.TrimSpace(name[i+1:])
+	if !groupPattern.MatchString(group) || notGroups[strings.ToLower(group)] {
+		return name, ""
+	}
+	// an hyphen surrounded by spaces is part of the title, like "Mission Impossible - Fallout",
+	// and a name with a single word, like "Spider-Man", has no group
+	if strings.HasSuffix(name[:i], " ") || !tokenSeparators.MatchString(name[:i]) {
+		return name, ""
+	}
+
+	return name[:i], group
+}
+
+// Parse extracts the release metadata from a release name such as
+// "The.Matrix.1999.1080p.BluRay.x264.DTS-HD.MA.5.1-FGT"
+func Parse(name string) Info {
+	var info Info
+
+	name = strings.TrimSpace(name)
+	for _, ext := range extensions {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	name = trailingTags.ReplaceAllString(name, "")
+	name, info.Group = splitGroup(name)
+
+	tokens := tokenSeparators.Split(strings.TrimSpace(name), -1)
+
+	// The year is the last year-like token, as long as it is not the first token: in
+	// "2001.A.Space.Odyssey.1968" the title is "2001 A Space Odyssey"
+	titleEnd := -1
+	for i := len(tokens) - 1; i > 0; i-- {
+		if yearPattern.MatchString(tokens[i]) {
+			info.Year, _ = strconv.Atoi(tokens[i])
+			titleEnd = i
+			break
+		}
+	}
+	if titleEnd < 0 {
+		titleEnd = len(tokens)
+		for i := 1; i < len(tokens); i++ {
+			if isMarker(tokens[i]) {
+				titleEnd = i
+				break
+			}
+		}
+	}
+
+	info.Title = strings.TrimSpace(strings.Join(tokens[:titleEnd], " "))
+	info.Title = strings.TrimSuffix(info.Title, " -")
+
+	rest := ""
+	if titleEnd < len(tokens) {
+		rest = normalize(strings.Join(tokens[titleEnd:], " "))
+	}
+	if info.Year != 0 {
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, strconv.Itoa(info.Year)))
+	}
+
+	info.Resolution, _ = match(resolutionPatterns, rest)
+	info.Source, _ = match(sourcePatterns, rest)
+	info.VideoCodec, _ = match(videoCodecPatterns, rest)
+	info.AudioCodec, _ = match(audioCodecPatterns, rest)
+	if strings.Contains(" "+rest+" ", " atmos ") {
+		if info.AudioCodec == "" {
+			info.AudioCodec = "TrueHD"
+		}
+		info.AudioCodec += " Atmos"
+	}
+	if info.AudioCodec != "" {
+		if m := channelsPattern.FindStringSubmatch(rest); m != nil {
+			info.AudioChannels = m[1] + "." + m[2]
+		}
+	}
+
+	info.HDR = matchAll(hdrPatterns, rest)
+	info.Languages = matchAll(languagePatterns, rest)
+	info.Edition = strings.Join(matchAll(editionPatterns, rest), " ")
+
+	_, properPos := match([]pattern{properPattern}, rest)
+	_, repackPos := match([]pattern{repackPattern}, rest)
+	info.Proper = properPos >= 0
+	info.Repack = repackPos >= 0
+
+	return info
+}
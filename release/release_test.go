@@ -0,0 +1,331 @@
+package release
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected Info
+	}{
+		{
+			"The.Matrix.1999.1080p.BluRay.x264.DTS-HD.MA.5.1-FGT",
+			Info{Title: "The Matrix", Year: 1999, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Group: "FGT"},
+		},
+		{
+			"Inception.2010.720p.BluRay.x264-SPARKS",
+			Info{Title: "Inception", Year: 2010, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Group: "SPARKS"},
+		},
+		{
+			"Dune.Part.Two.2024.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR.H.265-FLUX",
+			Info{Title: "Dune Part Two", Year: 2024, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "x265", AudioCodec: "DD+ Atmos", AudioChannels: "5.1", HDR: []string{"DV", "HDR"}, Group: "FLUX"},
+		},
+		{
+			"Oppenheimer.2023.1080p.WEBRip.x264.AAC5.1-YTS",
+			Info{Title: "Oppenheimer", Year: 2023, Resolution: "1080p", Source: "WEBRip", VideoCodec: "x264", AudioCodec: "AAC", AudioChannels: "5.1", Group: "YTS"},
+		},
+		{
+			"Blade.Runner.2049.2017.2160p.UHD.BluRay.REMUX.HDR.HEVC.Atmos-EPSiLON",
+			Info{Title: "Blade Runner 2049", Year: 2017, Resolution: "2160p", Source: "Remux", VideoCodec: "x265", AudioCodec: "TrueHD Atmos", HDR: []string{"HDR"}, Group: "EPSiLON"},
+		},
+		{
+			"2001.A.Space.Odyssey.1968.REMASTERED.1080p.BluRay.x264-AMIABLE",
+			Info{Title: "2001 A Space Odyssey", Year: 1968, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Edition: "Remastered", Group: "AMIABLE"},
+		},
+		{
+			"1917.2019.1080p.BluRay.x264-SPARKS",
+			Info{Title: "1917", Year: 2019, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "SPARKS"},
+		},
+		{
+			"2012.2009.720p.BluRay.x264-METiS",
+			Info{Title: "2012", Year: 2009, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Group: "METiS"},
+		},
+		{
+			"Blade.Runner.1982.The.Final.Cut.1080p.BluRay.DTS.x264-DON",
+			Info{Title: "Blade Runner", Year: 1982, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS", Edition: "Final Cut", Group: "DON"},
+		},
+		{
+			"Kingdom.of.Heaven.2005.Directors.Cut.1080p.BluRay.x264-HANDJOB",
+			Info{Title: "Kingdom of Heaven", Year: 2005, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Edition: "Director's Cut", Group: "HANDJOB"},
+		},
+		{
+			"The.Lord.of.the.Rings.The.Fellowship.of.the.Ring.2001.EXTENDED.1080p.BluRay.x264-SiNNERS",
+			Info{Title: "The Lord of the Rings The Fellowship of the Ring", Year: 2001, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Edition: "Extended", Group: "SiNNERS"},
+		},
+		{
+			"Aliens.1986.Special.Edition.720p.BluRay.DD5.1.x264-EbP",
+			Info{Title: "Aliens", Year: 1986, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DD", AudioChannels: "5.1", Edition: "Special Edition", Group: "EbP"},
+		},
+		{
+			"Mad.Max.Fury.Road.2015.PROPER.1080p.BluRay.x264-GECKOS",
+			Info{Title: "Mad Max Fury Road", Year: 2015, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Proper: true, Group: "GECKOS"},
+		},
+		{
+			"Joker.2019.REPACK.720p.WEB-DL.DD5.1.H264-CMRG",
+			Info{Title: "Joker", Year: 2019, Resolution: "720p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD", AudioChannels: "5.1", Repack: true, Group: "CMRG"},
+		},
+		{
+			"Parasite.2019.KOREAN.1080p.BluRay.x264.DTS-HD.MA.5.1-FGT",
+			Info{Title: "Parasite", Year: 2019, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Languages: []string{"Korean"}, Group: "FGT"},
+		},
+		{
+			"Amelie.2001.FRENCH.720p.BluRay.x264-HANDJOB",
+			Info{Title: "Amelie", Year: 2001, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Languages: []string{"French"}, Group: "HANDJOB"},
+		},
+		{
+			"Intouchables.2011.MULTi.1080p.BluRay.x264-LOST",
+			Info{Title: "Intouchables", Year: 2011, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Languages: []string{"Multi"}, Group: "LOST"},
+		},
+		{
+			"Das.Boot.1981.GERMAN.DL.1080p.BluRay.x264-DETAiLS",
+			Info{Title: "Das Boot", Year: 1981, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Languages: []string{"German"}, Group: "DETAiLS"},
+		},
+		{
+			"Spider-Man.Into.the.Spider-Verse.2018.1080p.WEB-DL.H264.AC3-EVO",
+			Info{Title: "Spider-Man Into the Spider-Verse", Year: 2018, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD", Group: "EVO"},
+		},
+		{
+			"Mission.Impossible.-.Fallout.2018.1080p.BluRay.x264-DRONES",
+			Info{Title: "Mission Impossible - Fallout", Year: 2018, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "DRONES"},
+		},
+		{
+			"Avengers.Endgame.2019.2160p.BluRay.x265.10bit.HDR10+.TrueHD.7.1.Atmos-TERMiNAL",
+			Info{Title: "Avengers Endgame", Year: 2019, Resolution: "2160p", Source: "BluRay", VideoCodec: "x265", AudioCodec: "TrueHD Atmos", AudioChannels: "7.1", HDR: []string{"HDR10+"}, Group: "TERMiNAL"},
+		},
+		{
+			"Tenet.2020.2160p.UHD.BluRay.x265.HDR10.DTS-X-TERMiNAL",
+			Info{Title: "Tenet", Year: 2020, Resolution: "2160p", Source: "BluRay", VideoCodec: "x265", AudioCodec: "DTS-X", HDR: []string{"HDR10"}, Group: "TERMiNAL"},
+		},
+		{
+			"The.Shawshank.Redemption.1994.REMASTERED.1080p.BluRay.x265.HEVC.10bit.AAC.5.1-Tigole",
+			Info{Title: "The Shawshank Redemption", Year: 1994, Resolution: "1080p", Source: "BluRay", VideoCodec: "x265", AudioCodec: "AAC", AudioChannels: "5.1", Edition: "Remastered", Group: "Tigole"},
+		},
+		{
+			"Pulp.Fiction.1994.1080p.BluRay.Remux.AVC.DTS-HD.MA.5.1-KRaLiMaRKo",
+			Info{Title: "Pulp Fiction", Year: 1994, Resolution: "1080p", Source: "Remux", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Group: "KRaLiMaRKo"},
+		},
+		{
+			"Interstellar.2014.IMAX.1080p.BluRay.x264.DTS-HD.MA.5.1-SWTYBLZ",
+			Info{Title: "Interstellar", Year: 2014, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Edition: "IMAX", Group: "SWTYBLZ"},
+		},
+		{
+			"The.Dark.Knight.2008.IMAX.Edition.1080p.BluRay.x264.DTS-HD.MA.5.1-SWTYBLZ",
+			Info{Title: "The Dark Knight", Year: 2008, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Edition: "IMAX", Group: "SWTYBLZ"},
+		},
+		{
+			"Gladiator.2000.Extended.Remastered.720p.BluRay.x264-Grym",
+			Info{Title: "Gladiator", Year: 2000, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Edition: "Extended Remastered", Group: "Grym"},
+		},
+		{
+			"Apocalypse.Now.1979.Final.Cut.2160p.UHD.BluRay.REMUX.HDR.HEVC.DTS-HD.MA.5.1-FGT",
+			Info{Title: "Apocalypse Now", Year: 1979, Resolution: "2160p", Source: "Remux", VideoCodec: "x265", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", HDR: []string{"HDR"}, Edition: "Final Cut", Group: "FGT"},
+		},
+		{
+			"Alien.1979.Directors.Cut.REMASTERED.1080p.BluRay.x264-AMIABLE",
+			Info{Title: "Alien", Year: 1979, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Edition: "Director's Cut Remastered", Group: "AMIABLE"},
+		},
+		{
+			"Wonder.Woman.1984.2020.1080p.HMAX.WEB-DL.DDP5.1.Atmos.x264-EVO",
+			Info{Title: "Wonder Woman 1984", Year: 2020, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD+ Atmos", AudioChannels: "5.1", Group: "EVO"},
+		},
+		{
+			"Soul.2020.1080p.DSNP.WEB-DL.DDP5.1.Atmos.H.264-CMRG",
+			Info{Title: "Soul", Year: 2020, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD+ Atmos", AudioChannels: "5.1", Group: "CMRG"},
+		},
+		{
+			"The.Irishman.2019.1080p.NF.WEBRip.DDP5.1.x264-NTG",
+			Info{Title: "The Irishman", Year: 2019, Resolution: "1080p", Source: "WEBRip", VideoCodec: "x264", AudioCodec: "DD+", AudioChannels: "5.1", Group: "NTG"},
+		},
+		{
+			"Roma.2018.SPANISH.1080p.NF.WEB-DL.DDP5.1.x264-NTG",
+			Info{Title: "Roma", Year: 2018, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD+", AudioChannels: "5.1", Languages: []string{"Spanish"}, Group: "NTG"},
+		},
+		{
+			"Dune.2021.2160p.HMAX.WEB-DL.DDP5.1.Atmos.HDR.HEVC-EVO",
+			Info{Title: "Dune", Year: 2021, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "x265", AudioCodec: "DD+ Atmos", AudioChannels: "5.1", HDR: []string{"HDR"}, Group: "EVO"},
+		},
+		{
+			"No.Time.to.Die.2021.2160p.WEB-DL.DDP5.1.Atmos.DV.HDR10.HEVC-CMRG",
+			Info{Title: "No Time to Die", Year: 2021, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "x265", AudioCodec: "DD+ Atmos", AudioChannels: "5.1", HDR: []string{"DV", "HDR10"}, Group: "CMRG"},
+		},
+		{
+			"The.Batman.2022.1080p.WEBRip.x265-RARBG",
+			Info{Title: "The Batman", Year: 2022, Resolution: "1080p", Source: "WEBRip", VideoCodec: "x265", Group: "RARBG"},
+		},
+		{
+			"Top.Gun.Maverick.2022.HDCAM.x264-NOGRP",
+			Info{Title: "Top Gun Maverick", Year: 2022, Source: "CAM", VideoCodec: "x264", Group: "NOGRP"},
+		},
+		{
+			"Avatar.The.Way.of.Water.2022.HDTS.x264-NOGRP",
+			Info{Title: "Avatar The Way of Water", Year: 2022, Source: "TELESYNC", VideoCodec: "x264", Group: "NOGRP"},
+		},
+		{
+			"Babylon.2022.DVDSCR.XviD.AC3-NOGRP",
+			Info{Title: "Babylon", Year: 2022, Source: "SCREENER", VideoCodec: "XviD", AudioCodec: "DD", Group: "NOGRP"},
+		},
+		{
+			"Fight.Club.1999.DVDRip.XviD-DiAMOND",
+			Info{Title: "Fight Club", Year: 1999, Source: "DVD", VideoCodec: "XviD", Group: "DiAMOND"},
+		},
+		{
+			"Casablanca.1942.576p.BluRay.x264-NOGRP",
+			Info{Title: "Casablanca", Year: 1942, Resolution: "576p", Source: "BluRay", VideoCodec: "x264", Group: "NOGRP"},
+		},
+		{
+			"Metropolis.1927.480p.DVDRip.x264-NOGRP",
+			Info{Title: "Metropolis", Year: 1927, Resolution: "480p", Source: "DVD", VideoCodec: "x264", Group: "NOGRP"},
+		},
+		{
+			"Some.Movie.2019.720p.HDTV.x264-KILLERS",
+			Info{Title: "Some Movie", Year: 2019, Resolution: "720p", Source: "HDTV", VideoCodec: "x264", Group: "KILLERS"},
+		},
+		{
+			"Lawrence.of.Arabia.1962.1080p.BluRay.VC-1.DTS-HD.MA.5.1-FGT",
+			Info{Title: "Lawrence of Arabia", Year: 1962, Resolution: "1080p", Source: "BluRay", VideoCodec: "VC-1", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", Group: "FGT"},
+		},
+		{
+			"Everything.Everywhere.All.at.Once.2022.1080p.AMZN.WEB-DL.DDP5.1.H.264-SMURF",
+			Info{Title: "Everything Everywhere All at Once", Year: 2022, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD+", AudioChannels: "5.1", Group: "SMURF"},
+		},
+		{
+			"The Godfather (1972) 1080p BluRay x264 AAC 2.0 [YTS.MX]",
+			Info{Title: "The Godfather", Year: 1972, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "AAC", AudioChannels: "2.0"},
+		},
+		{
+			"Heat (1995) [1080p] [BluRay] [5.1] [YTS.MX]",
+			Info{Title: "Heat", Year: 1995, Resolution: "1080p", Source: "BluRay"},
+		},
+		{
+			"Goodfellas 1990 720p BluRay x264-HANDJOB",
+			Info{Title: "Goodfellas", Year: 1990, Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Group: "HANDJOB"},
+		},
+		{
+			"The_Thing_1982_1080p_BluRay_x264-CiNEFiLE",
+			Info{Title: "The Thing", Year: 1982, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "CiNEFiLE"},
+		},
+		{
+			"Jaws.1975.1080p.BluRay.x264-CiNEFiLE.mkv",
+			Info{Title: "Jaws", Year: 1975, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "CiNEFiLE"},
+		},
+		{
+			"Se7en.1995.REMASTERED.720p.BluRay.999MB.HQ.x265.10bit-GalaxyRG",
+			Info{Title: "Se7en", Year: 1995, Resolution: "720p", Source: "BluRay", VideoCodec: "x265", Edition: "Remastered", Group: "GalaxyRG"},
+		},
+		{
+			"Arrival.2016.1080p.BluRay.x264-SPARKS[rarbg]",
+			Info{Title: "Arrival", Year: 2016, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "SPARKS"},
+		},
+		{
+			"Taxi.Driver.1976.40th.Anniversary.Edition.1080p.BluRay.x264-PSYCHD",
+			Info{Title: "Taxi Driver", Year: 1976, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "PSYCHD"},
+		},
+		{
+			"Brazil.1985.Criterion.Collection.1080p.BluRay.x264.FLAC.1.0-FGT",
+			Info{Title: "Brazil", Year: 1985, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "FLAC", AudioChannels: "1.0", Edition: "Criterion", Group: "FGT"},
+		},
+		{
+			"The.Wizard.of.Oz.1939.Open.Matte.1080p.WEB-DL.AAC2.0.H.264",
+			Info{Title: "The Wizard of Oz", Year: 1939, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "AAC", AudioChannels: "2.0", Edition: "Open Matte"},
+		},
+		{
+			"Seven.Samurai.1954.JAPANESE.1080p.BluRay.x264.FLAC.1.0-EDPH",
+			Info{Title: "Seven Samurai", Year: 1954, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "FLAC", AudioChannels: "1.0", Languages: []string{"Japanese"}, Group: "EDPH"},
+		},
+		{
+			"Cidade.de.Deus.2002.PORTUGUESE.1080p.BluRay.x264.DTS-FGT",
+			Info{Title: "Cidade de Deus", Year: 2002, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS", Languages: []string{"Portuguese"}, Group: "FGT"},
+		},
+		{
+			"Le.Fabuleux.Destin.d.Amelie.Poulain.2001.MULTi.TRUEFRENCH.1080p.BluRay.x264-NOGRP",
+			Info{Title: "Le Fabuleux Destin d Amelie Poulain", Year: 2001, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Languages: []string{"Multi", "French"}, Group: "NOGRP"},
+		},
+		{
+			"Oldboy.2003.KOREAN.REMASTERED.2160p.UHD.BluRay.x265.10bit.HDR.DTS-HD.MA.5.1-SWTYBLZ",
+			Info{Title: "Oldboy", Year: 2003, Resolution: "2160p", Source: "BluRay", VideoCodec: "x265", AudioCodec: "DTS-HD MA", AudioChannels: "5.1", HDR: []string{"HDR"}, Edition: "Remastered", Languages: []string{"Korean"}, Group: "SWTYBLZ"},
+		},
+		{
+			"The.Hateful.Eight.2015.Extended.Cut.1080p.WEB-DL.DD5.1.x264-NOGRP",
+			Info{Title: "The Hateful Eight", Year: 2015, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", AudioCodec: "DD", AudioChannels: "5.1", Edition: "Extended", Group: "NOGRP"},
+		},
+		{
+			"Watchmen.2009.Ultimate.Cut.1080p.BluRay.x264-NOGRP",
+			Info{Title: "Watchmen", Year: 2009, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Edition: "Ultimate Edition", Group: "NOGRP"},
+		},
+		{
+			"Movie.Without.Year.1080p.BluRay.x264-GRP",
+			Info{Title: "Movie Without Year", Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Group: "GRP"},
+		},
+		{
+			"Unrated.Movie.UNRATED.720p.BluRay.x264-GRP",
+			Info{Title: "Unrated Movie", Resolution: "720p", Source: "BluRay", VideoCodec: "x264", Edition: "Unrated", Group: "GRP"},
+		},
+		{
+			"The.Revenant.2015.1080p.BluRay.x264.DTS-WiKi",
+			Info{Title: "The Revenant", Year: 2015, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS", Group: "WiKi"},
+		},
+		{
+			"Nomadland.2020.1080p.WEB.H264-NAISU",
+			Info{Title: "Nomadland", Year: 2020, Resolution: "1080p", Source: "WEB-DL", VideoCodec: "x264", Group: "NAISU"},
+		},
+		{
+			"Tar.2022.2160p.WEB.H265-NAISU",
+			Info{Title: "Tar", Year: 2022, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "x265", Group: "NAISU"},
+		},
+		{
+			"Gravity.2013.1080p.BluRay.AV1.Opus.5.1-NOGRP",
+			Info{Title: "Gravity", Year: 2013, Resolution: "1080p", Source: "BluRay", VideoCodec: "AV1", AudioCodec: "Opus", AudioChannels: "5.1", Group: "NOGRP"},
+		},
+		{
+			"Hereditary.2018.HLG.2160p.WEB-DL.DDP5.1.H.265-NOGRP",
+			Info{Title: "Hereditary", Year: 2018, Resolution: "2160p", Source: "WEB-DL", VideoCodec: "x265", AudioCodec: "DD+", AudioChannels: "5.1", HDR: []string{"HLG"}, Group: "NOGRP"},
+		},
+		{
+			"Up.2009.REAL.PROPER.1080p.BluRay.x264-NOGRP",
+			Info{Title: "Up", Year: 2009, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", Proper: true, Group: "NOGRP"},
+		},
+		{
+			"Coco.2017.1080p.BluRay.x264.DTS-HD.MA.7.1",
+			Info{Title: "Coco", Year: 2017, Resolution: "1080p", Source: "BluRay", VideoCodec: "x264", AudioCodec: "DTS-HD MA", AudioChannels: "7.1"},
+		},
+		{
+			"Prisoners.2013.1080p.WEB-DL",
+			Info{Title: "Prisoners", Year: 2013, Resolution: "1080p", Source: "WEB-DL"},
+		},
+		{
+			"Spider-Man",
+			Info{Title: "Spider-Man"},
+		},
+		{
+			"",
+			Info{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		result := Parse(testCase.name)
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("Parse(%q)\n got: %+v\nwant: %+v", testCase.name, result, testCase.expected)
+		}
+	}
+}
+
+func TestInfo_Quality(t *testing.T) {
+	testCases := []struct {
+		info     Info
+		expected string
+	}{
+		{Info{Resolution: "1080p", Source: "BluRay"}, "1080p BluRay"},
+		{Info{Resolution: "720p"}, "720p"},
+		{Info{Source: "WEB-DL"}, "WEB-DL"},
+		{Info{}, ""},
+	}
+
+	for _, testCase := range testCases {
+		if result := testCase.info.Quality(); result != testCase.expected {
+			t.Errorf("expected %q, got %q", testCase.expected, result)
+		}
+	}
+}
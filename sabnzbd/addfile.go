@@ -0,0 +1,70 @@
+package sabnzbd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime/multipart"
+
+	"github.com/pkg/errors"
+)
+
+// AddFile uploads the content of an nzb file. It returns the ids of the created jobs.
+func (s Sabnzbd) AddFile(filename string, content []byte) ([]string, error) {
+	if s.Host == "" {
+		return nil, errors.New("sabnzbd structure has no host")
+	}
+	if len(content) == 0 {
+		return nil, errors.New("content is required for adding a file")
+	}
+
+	u := s.url()
+	query := u.Query()
+	query.Set("mode", "addfile")
+	u.RawQuery = query.Encode()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("name", filename)
+	if err != nil {
+		return nil, errors.Wrap(err, "writer.CreateFormFile")
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, errors.Wrap(err, "part.Write")
+	}
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "writer.Close")
+	}
+
+	s.log("HTTP post: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Post(u.String(), writer.FormDataContentType(), &body)
+	if err != nil {
+		return nil, errors.Wrap(err, "http.Post")
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Println(fmt.Errorf("Body.Close: %w", err))
+		}
+	}(resp.Body)
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "ioutil.ReadAll")
+	}
+
+	var apiResponse AddUrlResponse
+	if err := json.Unmarshal(respBody, &apiResponse); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+
+	if !apiResponse.Status {
+		return nil, errors.New("response status is false")
+	}
+
+	return apiResponse.NzoIds, nil
+}
@@ -0,0 +1,47 @@
+package sabnzbd
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type DeleteResponse struct {
+	Status bool     `json:"status"`
+	NzoIds []string `json:"nzo_ids"`
+}
+
+func (s Sabnzbd) delete(mode string, deleteFiles bool, nzoIds []string) error {
+	if len(nzoIds) == 0 {
+		return errors.New("at least one nzo_id is required")
+	}
+
+	query := url.Values{}
+	query.Set("name", "delete")
+	query.Set("value", strings.Join(nzoIds, ","))
+	if deleteFiles {
+		query.Set("del_files", "1")
+	}
+
+	var apiResponse DeleteResponse
+	if err := s.apiGet(mode, query, &apiResponse); err != nil {
+		return errors.Wrap(err, "s.apiGet")
+	}
+
+	if !apiResponse.Status {
+		return errors.New("response status is false")
+	}
+
+	return nil
+}
+
+// DeleteQueue removes jobs from the queue. If deleteFiles is true, downloaded files are removed as well.
+func (s Sabnzbd) DeleteQueue(deleteFiles bool, nzoIds ...string) error {
+	return s.delete("queue", deleteFiles, nzoIds)
+}
+
+// DeleteHistory removes jobs from the history. If deleteFiles is true, files of failed jobs are removed as well.
+func (s Sabnzbd) DeleteHistory(deleteFiles bool, nzoIds ...string) error {
+	return s.delete("history", deleteFiles, nzoIds)
+}
@@ -0,0 +1,135 @@
+package sabnzbd
+
+import (
+	"strconv"
+
+	"pomegranate/downloader"
+
+	"github.com/pkg/errors"
+)
+
+// Sabnzbd implements downloader.Downloader
+var _ downloader.Downloader = Sabnzbd{}
+
+func (s Sabnzbd) Name() string {
+	return "sabnzbd"
+}
+
+func (s Sabnzbd) firstId(ids []string) (string, error) {
+	if len(ids) < 1 {
+		return "", errors.New("sabnzbd returned no ids")
+	}
+	if len(ids) > 1 {
+		s.log("I don't know what to do with this many ids! %s\n", ids)
+	}
+
+	return ids[0], nil
+}
+
+func (s Sabnzbd) AddByURL(url string, name string) (string, error) {
+	ids, err := s.AddUrl(AddUrlParams{Name: url, NzbName: name})
+	if err != nil {
+		return "", errors.Wrap(err, "s.AddUrl")
+	}
+
+	return s.firstId(ids)
+}
+
+func (s Sabnzbd) AddByContent(filename string, content []byte) (string, error) {
+	ids, err := s.AddFile(filename, content)
+	if err != nil {
+		return "", errors.Wrap(err, "s.AddFile")
+	}
+
+	return s.firstId(ids)
+}
+
+func queueJob(slot QueueSlot) downloader.Job {
+	job := downloader.Job{
+		ID:   slot.NzoId,
+		Name: slot.Filename,
+	}
+
+	switch slot.Status {
+	case "Downloading":
+		job.Status = downloader.JobDownloading
+	case "Paused":
+		job.Status = downloader.JobPaused
+	default:
+		job.Status = downloader.JobQueued
+	}
+
+	if progress, err := strconv.ParseFloat(slot.Percentage, 64); err == nil {
+		job.Progress = progress
+	}
+
+	return job
+}
+
+func historyJob(slot HistorySlot) downloader.Job {
+	job := downloader.Job{
+		ID:       slot.NzoId,
+		Name:     slot.Name,
+		Progress: 100,
+	}
+
+	switch slot.Status {
+	case HistoryStatusCompleted:
+		job.Status = downloader.JobCompleted
+		job.StoragePath = slot.Storage
+	case HistoryStatusFailed:
+		job.Status = downloader.JobFailed
+		job.FailMessage = slot.FailMessage
+	default:
+		job.Status = downloader.JobProcessing
+	}
+
+	return job
+}
+
+func (s Sabnzbd) QueueJobs(ids ...string) ([]downloader.Job, error) {
+	queue, err := s.Queue(QueueRequestParams{NzoIds: ids})
+	if err != nil {
+		return nil, errors.Wrap(err, "s.Queue")
+	}
+
+	jobs := make([]downloader.Job, 0, len(queue.Slots))
+	for _, slot := range queue.Slots {
+		jobs = append(jobs, queueJob(slot))
+	}
+
+	return jobs, nil
+}
+
+func (s Sabnzbd) HistoryJobs(ids ...string) ([]downloader.Job, error) {
+	history, err := s.History(HistoryRequestParams{NzoIds: ids})
+	if err != nil {
+		return nil, errors.Wrap(err, "s.History")
+	}
+
+	jobs := make([]downloader.Job, 0, len(history.Slots))
+	for _, slot := range history.Slots {
+		jobs = append(jobs, historyJob(slot))
+	}
+
+	return jobs, nil
+}
+
+func (s Sabnzbd) Pause() error {
+	return s.PauseQueue()
+}
+
+func (s Sabnzbd) Resume() error {
+	return s.ResumeQueue()
+}
+
+// Delete removes a job from both the queue and the history, since the caller may not know where the job is
+func (s Sabnzbd) Delete(id string, deleteFiles bool) error {
+	queueErr := s.DeleteQueue(deleteFiles, id)
+	historyErr := s.DeleteHistory(deleteFiles, id)
+	if queueErr != nil && historyErr != nil {
+		return errors.Errorf("cannot delete job %s: queue: %s, history: %s", id, queueErr, historyErr)
+	}
+
+	return nil
+}
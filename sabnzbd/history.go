@@ -0,0 +1,104 @@
+package sabnzbd
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Possible values for HistorySlot.Status
+const (
+	HistoryStatusCompleted  = "Completed"
+	HistoryStatusFailed     = "Failed"
+	HistoryStatusQueued     = "Queued"
+	HistoryStatusQuickCheck = "QuickCheck"
+	HistoryStatusVerifying  = "Verifying"
+	HistoryStatusRepairing  = "Repairing"
+	HistoryStatusFetching   = "Fetching"
+	HistoryStatusExtracting = "Extracting"
+	HistoryStatusMoving     = "Moving"
+	HistoryStatusRunning    = "Running"
+)
+
+type HistorySlot struct {
+	NzoId        string `json:"nzo_id"`
+	Name         string `json:"name"`
+	NzbName      string `json:"nzb_name"`
+	Category     string `json:"category"`
+	Status       string `json:"status"`
+	FailMessage  string `json:"fail_message"`
+	Storage      string `json:"storage"` // Final location of the job files
+	Path         string `json:"path"`    // Temporary download folder
+	Size         string `json:"size"`
+	Bytes        int64  `json:"bytes"`
+	Downloaded   int64  `json:"downloaded"`
+	Completed    int64  `json:"completed"` // Unix timestamp of job completion
+	DownloadTime int64  `json:"download_time"`
+	PostprocTime int64  `json:"postproc_time"`
+	Retry        int    `json:"retry"`
+	Url          string `json:"url"`
+	Script       string `json:"script"`
+	ScriptLine   string `json:"script_line"`
+	ActionLine   string `json:"action_line"`
+	Report       string `json:"report"`
+	Password     string `json:"password"`
+	Md5sum       string `json:"md5sum"`
+	DuplicateKey string `json:"duplicate_key"`
+	Loaded       bool   `json:"loaded"`
+	HasRating    bool   `json:"has_rating"`
+}
+
+type History struct {
+	Noofslots         int           `json:"noofslots"`
+	Ppslots           int           `json:"ppslots"` // Number of jobs in post-processing
+	DaySize           string        `json:"day_size"`
+	WeekSize          string        `json:"week_size"`
+	MonthSize         string        `json:"month_size"`
+	TotalSize         string        `json:"total_size"`
+	LastHistoryUpdate int64         `json:"last_history_update"`
+	Slots             []HistorySlot `json:"slots"`
+}
+
+type HistoryRequestParams struct {
+	Start      int32    `json:"start"`       // Index of job to start at
+	Limit      int32    `json:"limit"`       // Number of jobs to display
+	Category   string   `json:"category"`    // Only return jobs in this category
+	Search     string   `json:"search"`      // Filter job names by search term
+	NzoIds     []string `json:"nzo_ids"`     // Filter jobs by nzo_ids
+	FailedOnly bool     `json:"failed_only"` // Only return failed jobs
+}
+
+type HistoryResponse struct {
+	History History `json:"history"`
+}
+
+func (s Sabnzbd) History(params HistoryRequestParams) (History, error) {
+	query := url.Values{}
+	if params.Start > 0 {
+		query.Set("start", strconv.Itoa(int(params.Start)))
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(int(params.Limit)))
+	}
+	if params.Category != "" {
+		query.Set("category", params.Category)
+	}
+	if params.Search != "" {
+		query.Set("search", params.Search)
+	}
+	if len(params.NzoIds) > 0 {
+		query.Set("nzo_ids", strings.Join(params.NzoIds, ","))
+	}
+	if params.FailedOnly {
+		query.Set("failed_only", "1")
+	}
+
+	var history HistoryResponse
+	if err := s.apiGet("history", query, &history); err != nil {
+		return History{}, errors.Wrap(err, "s.apiGet")
+	}
+
+	return history.History, nil
+}
@@ -0,0 +1,89 @@
+package sabnzbd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const historyPayload = `{
+  "history": {
+    "noofslots": 2,
+    "ppslots": 0,
+    "day_size": "1.9 G",
+    "week_size": "30.4 G",
+    "month_size": "167.3 G",
+    "total_size": "678.1 G",
+    "last_history_update": 1469210913,
+    "slots": [
+      {
+        "nzo_id": "SABnzbd_nzo_completed",
+        "name": "The.Matrix.1999.1080p.BluRay.x264-GRP",
+        "status": "Completed",
+        "fail_message": "",
+        "storage": "/downloads/complete/The.Matrix.1999.1080p.BluRay.x264-GRP",
+        "bytes": 8589934592,
+        "completed": 1469172988
+      },
+      {
+        "nzo_id": "SABnzbd_nzo_failed",
+        "name": "Inception.2010.720p.BluRay.x264-GRP",
+        "status": "Failed",
+        "fail_message": "Unpacking failed, archive requires a password",
+        "storage": "",
+        "bytes": 4294967296,
+        "completed": 1469172999
+      }
+    ]
+  }
+}`
+
+func TestSabnzbd_History(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("mode") != "history" {
+			t.Errorf("unexpected mode: %s", query.Get("mode"))
+		}
+		if query.Get("apikey") != "secret" {
+			t.Errorf("unexpected apikey: %s", query.Get("apikey"))
+		}
+		if query.Get("nzo_ids") != "SABnzbd_nzo_completed,SABnzbd_nzo_failed" {
+			t.Errorf("unexpected nzo_ids: %s", query.Get("nzo_ids"))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write([]byte(historyPayload)); err != nil {
+			t.Errorf("w.Write: %s", err)
+		}
+	}))
+	defer server.Close()
+
+	s := New(strings.TrimPrefix(server.URL, "http://"), "secret")
+
+	history, err := s.History(HistoryRequestParams{NzoIds: []string{"SABnzbd_nzo_completed", "SABnzbd_nzo_failed"}})
+	if err != nil {
+		t.Fatalf("s.History: %s", err)
+	}
+
+	if len(history.Slots) != 2 {
+		t.Fatalf("expected 2 slots, got %d", len(history.Slots))
+	}
+	if slot := history.Slots[0]; slot.Status != HistoryStatusCompleted || slot.Storage != "/downloads/complete/The.Matrix.1999.1080p.BluRay.x264-GRP" {
+		t.Errorf("unexpected completed slot: %+v", slot)
+	}
+	if slot := history.Slots[1]; slot.Status != HistoryStatusFailed || slot.FailMessage == "" {
+		t.Errorf("unexpected failed slot: %+v", slot)
+	}
+}
+
+func TestSabnzbd_HistoryError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	s := New(strings.TrimPrefix(server.URL, "http://"), "secret")
+	if _, err := s.History(HistoryRequestParams{}); err == nil {
+		t.Fatalf("s.History should fail when sabnzbd returns an error status")
+	}
+}
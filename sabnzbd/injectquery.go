@@ -22,15 +22,12 @@ func asString(i interface{}) string {
 		return strings.Join(pieces, ",")
 	}
 
+	// named types, like PriorityType, are converted through their underlying kind
 	switch typeOf.Kind() {
 	case reflect.String:
-		return i.(string)
-	case reflect.Int:
-		return strconv.Itoa(i.(int))
-	case reflect.Int32:
-		return strconv.Itoa(int(i.(int32)))
-	case reflect.Int64:
-		return strconv.Itoa(int(i.(int64)))
+		return valueOf.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(valueOf.Int(), 10)
 	}
 
 	return fmt.Sprintf("<%s>", reflect.TypeOf(i))
@@ -84,3 +84,23 @@ func TestInjectInUrlWithArray(t *testing.T) {
 		t.Fatalf("Generated url (%s) is not the expected url (%s)", generatedUrl, expectedUrl)
 	}
 }
+
+func TestInjectInUrlWithNamedTypes(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+	params := AddUrlParams{
+		Name:     "http://indexer/nzb",
+		Priority: PriorityHigh,
+	}
+
+	if err := InjectInUrl(u, params); err != nil {
+		t.Fatalf("Unexpected error in InjectInUrl: %s", err)
+	}
+
+	query := u.Query()
+	if query.Get("priority") != "1" {
+		t.Fatalf("Unexpected priority: %s", query.Get("priority"))
+	}
+	if query.Get("name") != "http://indexer/nzb" {
+		t.Fatalf("Unexpected name: %s", query.Get("name"))
+	}
+}
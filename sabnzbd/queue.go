@@ -7,7 +7,6 @@ import (
 	"io"
 	"io/ioutil"
 	"log"
-	"net/http"
 	"strconv"
 	"strings"
 )
@@ -80,10 +79,10 @@ type Queue struct {
 }
 
 type QueueRequestParams struct {
-	Start  int32   `json:"start"`   // Index of job to start at
-	Limit  int32   `json:"limit"`   // Number of jobs to display
-	Search string  `json:"search"`  // Filter job names by search term
-	NzoIds []int32 `json:"nzo_ids"` // Filter jobs by nzo_ids
+	Start  int32    `json:"start"`   // Index of job to start at
+	Limit  int32    `json:"limit"`   // Number of jobs to display
+	Search string   `json:"search"`  // Filter job names by search term
+	NzoIds []string `json:"nzo_ids"` // Filter jobs by nzo_ids
 }
 
 type QueueResponse struct {
@@ -107,11 +106,7 @@ func (s Sabnzbd) Queue(params QueueRequestParams) (Queue, error) {
 		query.Add("limit", strconv.Itoa(int(params.Limit)))
 	}
 	if len(params.NzoIds) > 0 {
-		var ids []string
-		for _, id := range params.NzoIds {
-			ids = append(ids, strconv.Itoa(int(id)))
-		}
-		query.Add("nzo_ids", strings.Join(ids, ","))
+		query.Add("nzo_ids", strings.Join(params.NzoIds, ","))
 	}
 	if params.Start > 0 {
 		query.Add("start", strconv.Itoa(int(params.Start)))
@@ -121,8 +116,8 @@ func (s Sabnzbd) Queue(params QueueRequestParams) (Queue, error) {
 	}
 	u.RawQuery = query.Encode()
 
-	s.log("HTTP get: %s\n", strings.ReplaceAll(u.String(), s.Apikey, "xxx"))
-	resp, err := http.Get(u.String())
+	s.log("HTTP get: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Get(u.String())
 	if err != nil {
 		return Queue{}, errors.Wrap(err, "http.Get")
 	}
@@ -154,8 +149,8 @@ func (s Sabnzbd) PauseQueue() error {
 	query.Add("mode", "pause")
 	u.RawQuery = query.Encode()
 
-	s.log("HTTP get: %s\n", strings.ReplaceAll(u.String(), s.Apikey, "xxx"))
-	resp, err := http.Get(u.String())
+	s.log("HTTP get: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Get(u.String())
 	if err != nil {
 		return errors.Wrap(err, "http.Get")
 	}
@@ -191,8 +186,8 @@ func (s Sabnzbd) ResumeQueue() error {
 	query.Add("mode", "resume")
 	u.RawQuery = query.Encode()
 
-	fmt.Printf("HTTP get (sabnzbd): %s\n", strings.ReplaceAll(u.String(), s.Apikey, "xxx"))
-	resp, err := http.Get(u.String())
+	s.log("HTTP get: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Get(u.String())
 	if err != nil {
 		return errors.Wrap(err, "http.Get")
 	}
@@ -20,6 +20,7 @@ type Sabnzbd struct {
 	Host   string
 	Apikey string
 	Logger Logger
+	Client *http.Client // http.DefaultClient when nil
 }
 
 func New(host string, apikey string) Sabnzbd {
@@ -29,6 +30,14 @@ func New(host string, apikey string) Sabnzbd {
 	}
 }
 
+func (s Sabnzbd) httpClient() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+
+	return s.Client
+}
+
 func (s Sabnzbd) log(format string, a ...interface{}) {
 	if s.Logger == nil {
 		return
@@ -37,6 +46,15 @@ func (s Sabnzbd) log(format string, a ...interface{}) {
 	s.Logger.Log("sabnzbd", format, a...)
 }
 
+// redact hides the api key from urls before they are logged
+func (s Sabnzbd) redact(u string) string {
+	if s.Apikey == "" {
+		return u
+	}
+
+	return strings.ReplaceAll(u, s.Apikey, "xxx")
+}
+
 func (s Sabnzbd) url() *url.URL {
 	// TODO: detect if scheme is present on Host
 	u := new(url.URL)
@@ -55,6 +73,51 @@ func (s Sabnzbd) url() *url.URL {
 	return u
 }
 
+// apiGet calls the api with the given mode and parameters, decoding the json response into dst
+func (s Sabnzbd) apiGet(mode string, params url.Values, dst interface{}) error {
+	if s.Host == "" {
+		return errors.New("sabnzbd structure has no host")
+	}
+
+	u := s.url()
+	query := u.Query()
+	query.Set("mode", mode)
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	s.log("HTTP get: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Get(u.String())
+	if err != nil {
+		return errors.Wrap(err, "http.Get")
+	}
+
+	defer func(Body io.ReadCloser) {
+		err := Body.Close()
+		if err != nil {
+			log.Println(fmt.Errorf("Body.Close: %w", err))
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrap(err, "ioutil.ReadAll")
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		return errors.Wrap(err, "json.Unmarshal")
+	}
+
+	return nil
+}
+
 type PriorityType int32
 
 const (
@@ -94,8 +157,8 @@ func (s Sabnzbd) AddUrl(params AddUrlParams) ([]string, error) {
 	}
 	u.RawQuery = query.Encode()
 
-	fmt.Printf("HTTP get (sabnzbd): %s\n", strings.ReplaceAll(u.String(), s.Apikey, "xxx"))
-	resp, err := http.Get(u.String())
+	s.log("HTTP get: %s\n", s.redact(u.String()))
+	resp, err := s.httpClient().Get(u.String())
 	if err != nil {
 		return nil, errors.Wrap(err, "http.Get")
 	}
@@ -118,7 +181,7 @@ func (s Sabnzbd) AddUrl(params AddUrlParams) ([]string, error) {
 	}
 
 	if !apiResponse.Status {
-		fmt.Println(string(body))
+		s.log("unexpected response: %s\n", string(body))
 		return nil, errors.New("response status is false")
 	}
 
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"pomegranate/database"
+	"pomegranate/metrics"
+
+	"github.com/pkg/errors"
+)
+
+// BucketName is the bucket where the last run time of each job is kept
+const BucketName = "jobs"
+
+type Logger interface {
+	Log(serviceName string, format string, a ...interface{})
+}
+
+// JobFunc is the work executed on every run of a job
+type JobFunc func(ctx context.Context) error
+
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      JobFunc
+}
+
+// Scheduler runs a set of jobs periodically in the background.
+// The last run of every job is persisted, so restarting the application
+// does not trigger every job right away.
+type Scheduler struct {
+	Logger Logger
+
+	db   *database.DB
+	jobs []Job
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(db *database.DB) (*Scheduler, error) {
+	if err := db.CreateBucket(BucketName); err != nil {
+		return nil, errors.Wrap(err, "db.CreateBucket")
+	}
+
+	return &Scheduler{db: db}, nil
+}
+
+func (s *Scheduler) log(format string, a ...interface{}) {
+	if s.Logger == nil {
+		return
+	}
+
+	s.Logger.Log("scheduler", format, a...)
+}
+
+// Add registers a new job. Jobs must be added before calling Start.
+func (s *Scheduler) Add(job Job) error {
+	if job.Name == "" {
+		return errors.New("job name cannot be empty")
+	}
+	if job.Interval <= 0 {
+		return errors.Errorf("invalid interval for job %s: %s", job.Name, job.Interval)
+	}
+	if job.Run == nil {
+		return errors.Errorf("job %s has nothing to run", job.Name)
+	}
+
+	s.jobs = append(s.jobs, job)
+
+	return nil
+}
+
+// Start launches every registered job in its own goroutine
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop cancels all running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+// LastRun returns the time the given job last started. A zero time is returned
+// if the job never ran.
+func (s *Scheduler) LastRun(name string) (time.Time, error) {
+	var t time.Time
+
+	data, err := s.db.Read([]byte(BucketName), []byte(name))
+	if err != nil {
+		return t, errors.Wrap(err, "db.Read")
+	}
+	if data == nil {
+		return t, nil
+	}
+
+	if err := t.UnmarshalText(data); err != nil {
+		return t, errors.Wrap(err, "time.UnmarshalText")
+	}
+
+	return t, nil
+}
+
+func (s *Scheduler) setLastRun(name string, t time.Time) error {
+	data, err := t.MarshalText()
+	if err != nil {
+		return errors.Wrap(err, "time.MarshalText")
+	}
+
+	if err := s.db.Store(BucketName, []byte(name), data); err != nil {
+		return errors.Wrap(err, "db.Store")
+	}
+
+	return nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	lastRun, err := s.LastRun(job.Name)
+	if err != nil {
+		s.log("cannot read last run of %s: %s\n", job.Name, err)
+	}
+
+	delay := time.Until(lastRun.Add(job.Interval))
+	if delay < 0 {
+		delay = 0
+	}
+	s.log("job %s will run in %s\n", job.Name, delay.Round(time.Second))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		s.execute(ctx, job)
+		timer.Reset(job.Interval)
+	}
+}
+
+func (s *Scheduler) execute(ctx context.Context, job Job) {
+	start := time.Now()
+
+	// the run is recorded even if it fails, so a broken indexer is not hammered on every restart
+	if err := s.setLastRun(job.Name, start); err != nil {
+		s.log("cannot store last run of %s: %s\n", job.Name, err)
+	}
+
+	err := job.Run(ctx)
+	metrics.ObserveJob(job.Name, time.Since(start), err)
+	if err != nil {
+		s.log("job %s failed after %s: %s\n", job.Name, time.Since(start).Round(time.Millisecond), err)
+		return
+	}
+
+	s.log("job %s finished in %s\n", job.Name, time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"pomegranate/database"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "schedulertest")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tmp); err != nil {
+			t.Errorf("error cleaning temp dir: %v", err)
+		}
+	}()
+
+	db, err := database.Open(fmt.Sprintf("%s/database.db", tmp))
+	if err != nil {
+		t.Fatalf("error opening database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("error closing database: %v", err)
+		}
+	}()
+
+	ran := make(chan struct{}, 10)
+	job := Job{
+		Name:     "test",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			ran <- struct{}{}
+			return nil
+		},
+	}
+
+	// first run happens right away, since the job never ran before
+	{
+		s, err := New(db)
+		if err != nil {
+			t.Fatalf("New: %s", err)
+		}
+		if err := s.Add(job); err != nil {
+			t.Fatalf("s.Add: %s", err)
+		}
+
+		s.Start(context.Background())
+		select {
+		case <-ran:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job did not run")
+		}
+		s.Stop()
+
+		lastRun, err := s.LastRun(job.Name)
+		if err != nil {
+			t.Fatalf("s.LastRun: %s", err)
+		}
+		if time.Since(lastRun) > time.Minute {
+			t.Fatalf("unexpected last run: %s", lastRun)
+		}
+	}
+
+	// a restart within the interval should not run the job again
+	{
+		s, err := New(db)
+		if err != nil {
+			t.Fatalf("New: %s", err)
+		}
+		if err := s.Add(job); err != nil {
+			t.Fatalf("s.Add: %s", err)
+		}
+
+		s.Start(context.Background())
+		select {
+		case <-ran:
+			t.Fatalf("job should wait for its interval")
+		case <-time.After(100 * time.Millisecond):
+		}
+		s.Stop()
+	}
+}
+
+func TestScheduler_Add(t *testing.T) {
+	s := &Scheduler{}
+	noop := func(ctx context.Context) error { return nil }
+
+	testCases := []struct {
+		job         Job
+		expectError bool
+	}{
+		{Job{Name: "ok", Interval: time.Minute, Run: noop}, false},
+		{Job{Interval: time.Minute, Run: noop}, true},
+		{Job{Name: "no interval", Run: noop}, true},
+		{Job{Name: "no func", Interval: time.Minute}, true},
+	}
+
+	for _, testCase := range testCases {
+		err := s.Add(testCase.job)
+		if testCase.expectError && err == nil {
+			t.Errorf("expected error adding job %q", testCase.job.Name)
+		}
+		if !testCase.expectError && err != nil {
+			t.Errorf("unexpected error adding job %q: %s", testCase.job.Name, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type MovieCreateRequest struct {
+	Identifier string `json:"identifier"` // Either an imdb id, like tt0133093, or a themoviedb id
+	ProfileID  string `json:"profile_id"`
+}
+
+// apiRouter serves the versioned api, mounted at /api/v1
+func (c Config) apiRouter() http.Handler {
+	r := chi.NewRouter()
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such route")
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+	})
+
+	r.Get("/movies", c.apiMovieListHandler)
+	r.Post("/movies", c.apiMovieCreateHandler)
+	r.Get("/movies/{imdbId}", c.apiMovieHandler)
+	r.Delete("/movies/{imdbId}", c.apiMovieDeleteHandler)
+	r.Post("/movies/{imdbId}/refresh", c.apiMovieRefreshHandler)
+	r.Get("/movies/{imdbId}/releases", c.apiMovieReleasesHandler)
+	r.Get("/movies/{imdbId}/history", c.apiMovieHistoryHandler)
+
+	r.Get("/history", c.historyHandler)
+	r.Get("/indexers", c.indexersHandler)
+	r.Post("/indexers/{host}/reset", c.indexerResetHandler)
+	r.Get("/events", c.eventsHandler)
+
+	r.Post("/apikey/rotate", c.apiKeyRotateHandler)
+
+	r.Get("/backup", c.backupHandler)
+	r.Get("/export", c.exportHandler)
+	r.Post("/import", c.importHandler)
+
+	return r
+}
+
+func writeApiJson(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := writeJson(w, data); err != nil {
+		log.Println(err)
+	}
+}
+
+func (c Config) apiMovieListHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := movieListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	movies, info, err := c.Manager.ListMovies(query)
+	if err != nil {
+		apiError(w, "manager.ListMovies", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, MovieListResponse{Movies: movies, Total: info.Total, NextCursor: info.NextCursor})
+}
+
+func (c Config) apiMovieCreateHandler(w http.ResponseWriter, r *http.Request) {
+	var req MovieCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+		return
+	}
+	if req.Identifier == "" {
+		writeError(w, http.StatusBadRequest, "identifier is required")
+		return
+	}
+
+	movie, err := c.Manager.SaveMovie(c.Tmdb, req.Identifier, req.ProfileID, false)
+	if err != nil {
+		apiError(w, "manager.SaveMovie", err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/movies/"+movie.ImdbId)
+	writeApiJson(w, http.StatusCreated, movie)
+}
+
+func (c Config) apiMovieHandler(w http.ResponseWriter, r *http.Request) {
+	movie, err := c.Manager.Movie(chi.URLParam(r, "imdbId"))
+	if err != nil {
+		apiError(w, "manager.Movie", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, movie)
+}
+
+func (c Config) apiMovieDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if err := c.Manager.DeleteMovie(chi.URLParam(r, "imdbId")); err != nil {
+		apiError(w, "manager.DeleteMovie", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c Config) apiMovieRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	movie, err := c.Manager.RefreshMovie(c.Tmdb, chi.URLParam(r, "imdbId"))
+	if err != nil {
+		apiError(w, "manager.RefreshMovie", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, movie)
+}
+
+func (c Config) apiMovieReleasesHandler(w http.ResponseWriter, r *http.Request) {
+	movie, err := c.Manager.Movie(chi.URLParam(r, "imdbId"))
+	if err != nil {
+		apiError(w, "manager.Movie", err)
+		return
+	}
+
+	releases, err := c.Manager.MovieReleases(movie)
+	if err != nil {
+		apiError(w, "manager.MovieReleases", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, MovieReleasesResponse{
+		ImdbId:    movie.ImdbId,
+		ProfileID: movie.ProfileID,
+		Releases:  releases,
+	})
+}
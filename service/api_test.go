@@ -0,0 +1,222 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"pomegranate/database"
+	"pomegranate/manager"
+	"pomegranate/models"
+	"pomegranate/newznab"
+	"strings"
+	"testing"
+)
+
+// newTestConfig creates a service configuration backed by a database in a temporary directory
+func newTestConfig(t *testing.T) Config {
+	db, err := database.Open(filepath.Join(t.TempDir(), "database.db"))
+	if err != nil {
+		t.Fatalf("database.Open: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("db.Close: %s", err)
+		}
+	})
+
+	m, err := manager.NewManager(db)
+	if err != nil {
+		t.Fatalf("manager.NewManager: %s", err)
+	}
+
+	return Config{DB: db, Manager: m}
+}
+
+func TestApiMovies(t *testing.T) {
+	config := newTestConfig(t)
+	movie := models.Movie{ImdbId: "tt0133093", Title: "The Matrix"}
+	if err := movie.Store(config.DB); err != nil {
+		t.Fatalf("movie.Store: %s", err)
+	}
+	config.Manager.Indexers = []newznab.Newznab{{Host: "indexer.example"}}
+
+	handler := Service(config)
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+
+	testCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{method: http.MethodGet, path: "/api/v1/movies?sort=-title&limit=10", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/movies?sort=plot", status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodGet, path: "/api/v1/movies?limit=-1", status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodGet, path: "/api/v1/movies?cursor=nope", status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodGet, path: "/api/v1/movies/tt0133093", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/movies/tt0133093/releases", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/movies/tt0000000", status: http.StatusNotFound, code: "not_found"},
+		{method: http.MethodGet, path: "/api/v1/movies/tt0133093/history", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/history?limit=10", status: http.StatusOK},
+		{method: http.MethodGet, path: "/api/v1/history?cursor=nope", status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodGet, path: "/api/v1/indexers", status: http.StatusOK},
+		{method: http.MethodPost, path: "/api/v1/indexers/indexer.example/reset", status: http.StatusNoContent},
+		{method: http.MethodPost, path: "/api/v1/indexers/unknown.example/reset", status: http.StatusNotFound, code: "not_found"},
+		{method: http.MethodPost, path: "/api/v1/movies", body: "{", status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodPost, path: "/api/v1/movies", body: `{"identifier": "the matrix"}`, status: http.StatusBadRequest, code: "bad_request"},
+		{method: http.MethodDelete, path: "/api/v1/movies/tt0133093", status: http.StatusNoContent},
+		{method: http.MethodDelete, path: "/api/v1/movies/tt0133093", status: http.StatusNotFound, code: "not_found"},
+		{method: http.MethodGet, path: "/api/v1/nothing", status: http.StatusNotFound, code: "not_found"},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d (%s)", tc.method, tc.path, tc.status, rec.Code, rec.Body.String())
+			continue
+		}
+		if tc.code == "" {
+			continue
+		}
+
+		var resp ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Errorf("%s %s: json.Unmarshal: %s", tc.method, tc.path, err)
+			continue
+		}
+		if resp.Error.Code != tc.code || resp.Error.Status != tc.status || resp.Error.Message == "" {
+			t.Errorf("%s %s: unexpected error body %+v", tc.method, tc.path, resp)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("%s %s: unexpected content type %s", tc.method, tc.path, contentType)
+		}
+	}
+}
+
+func TestMovieListPagination(t *testing.T) {
+	config := newTestConfig(t)
+	for _, movie := range []models.Movie{
+		{ImdbId: "tt0000001", Title: "C"},
+		{ImdbId: "tt0000002", Title: "A"},
+		{ImdbId: "tt0000003", Title: "B"},
+	} {
+		if err := movie.Store(config.DB); err != nil {
+			t.Fatalf("movie.Store: %s", err)
+		}
+	}
+
+	handler := Service(config)
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+	list := func(path string) ([]models.Movie, http.Header) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d (%s)", path, rec.Code, rec.Body.String())
+		}
+
+		// the body stays a bare array, with or without paging
+		var movies []models.Movie
+		if err := json.Unmarshal(rec.Body.Bytes(), &movies); err != nil {
+			t.Fatalf("GET %s: json.Unmarshal: %s", path, err)
+		}
+		return movies, rec.Header()
+	}
+
+	movies, header := list("/movie/list")
+	if len(movies) != 3 || header.Get("X-Total-Count") != "3" || header.Get("Link") != "" {
+		t.Errorf("unexpected unpaged list: %d movies, headers %v", len(movies), header)
+	}
+
+	var titles []string
+	path := "/movie/list?sort=title&limit=2"
+	for page := 0; path != ""; page++ {
+		if page > 2 {
+			t.Fatalf("too many pages")
+		}
+		movies, header := list(path)
+		if header.Get("X-Total-Count") != "3" {
+			t.Errorf("expected a total of 3, got %s", header.Get("X-Total-Count"))
+		}
+		for _, movie := range movies {
+			titles = append(titles, movie.Title)
+		}
+
+		path = ""
+		if link := header.Get("Link"); link != "" {
+			path = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+	}
+
+	if strings.Join(titles, ",") != "A,B,C" {
+		t.Errorf("unexpected titles: %v", titles)
+	}
+}
+
+func TestApiMoviesPagination(t *testing.T) {
+	config := newTestConfig(t)
+	for _, movie := range []models.Movie{
+		{ImdbId: "tt0000001", Title: "C"},
+		{ImdbId: "tt0000002", Title: "A"},
+		{ImdbId: "tt0000003", Title: "B"},
+	} {
+		if err := movie.Store(config.DB); err != nil {
+			t.Fatalf("movie.Store: %s", err)
+		}
+	}
+
+	handler := Service(config)
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+
+	var titles []string
+	path := "/api/v1/movies?sort=title&limit=2"
+	for page := 0; path != ""; page++ {
+		if page > 2 {
+			t.Fatalf("too many pages")
+		}
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Api-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d (%s)", path, rec.Code, rec.Body.String())
+		}
+
+		var resp MovieListResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("json.Unmarshal: %s", err)
+		}
+		if resp.Total != 3 {
+			t.Errorf("expected a total of 3, got %d", resp.Total)
+		}
+		for _, movie := range resp.Movies {
+			titles = append(titles, movie.Title)
+		}
+
+		path = ""
+		if resp.NextCursor != "" {
+			path = "/api/v1/movies?sort=title&limit=2&cursor=" + resp.NextCursor
+		}
+	}
+
+	if strings.Join(titles, ",") != "A,B,C" {
+		t.Errorf("unexpected titles: %v", titles)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+type ApiKeyResponse struct {
+	ApiKey string `json:"api_key"`
+}
+
+// requestApiKey returns the key sent by the client, either in the X-Api-Key header or the apikey query parameter
+func requestApiKey(r *http.Request) string {
+	if key := r.Header.Get("X-Api-Key"); key != "" {
+		return key
+	}
+
+	return r.URL.Query().Get("apikey")
+}
+
+// redactedURI returns the uri of a request with the value of its apikey parameter hidden
+func redactedURI(r *http.Request) string {
+	u := *r.URL
+	query := u.Query()
+	if !query.Has("apikey") {
+		return r.RequestURI
+	}
+	query.Set("apikey", "REDACTED")
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+// redactingFormatter keeps the api key out of the request logs
+type redactingFormatter struct {
+	middleware.LogFormatter
+}
+
+func (f redactingFormatter) NewLogEntry(r *http.Request) middleware.LogEntry {
+	logged := r.WithContext(r.Context())
+	logged.RequestURI = redactedURI(r)
+
+	return f.LogFormatter.NewLogEntry(logged)
+}
+
+// requestLogger logs every request like middleware.Logger, without the api key
+var requestLogger = middleware.RequestLogger(redactingFormatter{
+	&middleware.DefaultLogFormatter{Logger: log.New(os.Stdout, "", log.LstdFlags), NoColor: runtime.GOOS == "windows"},
+})
+
+// requireApiKey rejects requests without a valid api key. The key is read on every request, so a rotated
+// key is effective immediately.
+func (c Config) requireApiKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := c.Manager.ApiKey()
+		if err != nil {
+			log.Println(fmt.Errorf("manager.ApiKey: %w", err))
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+
+		given := requestApiKey(r)
+		if key == "" || given == "" || subtle.ConstantTimeCompare([]byte(key), []byte(given)) != 1 {
+			writeError(w, http.StatusUnauthorized, "missing or invalid api key")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// apiKeyRotateHandler replaces the api key. The new key is returned and the current one stops working.
+func (c Config) apiKeyRotateHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := c.Manager.RotateApiKey()
+	if err != nil {
+		apiError(w, "manager.RotateApiKey", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, ApiKeyResponse{ApiKey: key})
+}
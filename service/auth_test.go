@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRequireApiKey(t *testing.T) {
+	config := newTestConfig(t)
+	handler := Service(config)
+
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+	if len(key) != 32 {
+		t.Fatalf("unexpected api key %q", key)
+	}
+
+	request := func(method string, path string, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if header != "" {
+			req.Header.Set("X-Api-Key", header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	testCases := []struct {
+		path   string
+		header string
+		status int
+	}{
+		{path: "/", status: http.StatusOK},
+		{path: "/api/v1/movies", status: http.StatusUnauthorized},
+		{path: "/api/v1/movies", header: "wrong", status: http.StatusUnauthorized},
+		{path: "/api/v1/movies?apikey=wrong", status: http.StatusUnauthorized},
+		{path: "/api/v1/movies", header: key, status: http.StatusOK},
+		{path: "/api/v1/movies?apikey=" + key, status: http.StatusOK},
+		{path: "/movie/list", status: http.StatusUnauthorized},
+		{path: "/movie/list?apikey=" + key, status: http.StatusOK},
+		{path: "/metrics", status: http.StatusUnauthorized},
+		{path: "/metrics?apikey=" + key, status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		rec := request(http.MethodGet, tc.path, tc.header)
+		if rec.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.path, tc.status, rec.Code)
+		}
+		if tc.status == http.StatusUnauthorized && rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("%s: expected a json error", tc.path)
+		}
+	}
+
+	rec := request(http.MethodPost, "/api/v1/apikey/rotate", key)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rotate: expected status 200, got %d", rec.Code)
+	}
+	var resp ApiKeyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json.Unmarshal: %s", err)
+	}
+	if resp.ApiKey == "" || resp.ApiKey == key {
+		t.Fatalf("unexpected rotated key %q", resp.ApiKey)
+	}
+
+	if rec := request(http.MethodGet, "/api/v1/movies", key); rec.Code != http.StatusUnauthorized {
+		t.Errorf("old key: expected status 401, got %d", rec.Code)
+	}
+	if rec := request(http.MethodGet, "/api/v1/movies", resp.ApiKey); rec.Code != http.StatusOK {
+		t.Errorf("new key: expected status 200, got %d", rec.Code)
+	}
+}
+
+func TestRequestLoggerRedactsApiKey(t *testing.T) {
+	var logs bytes.Buffer
+	formatter := redactingFormatter{&middleware.DefaultLogFormatter{Logger: log.New(&logs, "", 0), NoColor: true}}
+	handler := middleware.RequestLogger(formatter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != "secret" {
+			t.Errorf("the handler should get the key, got %s", r.URL.RawQuery)
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/movie/list?apikey=secret&limit=2", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if line := logs.String(); strings.Contains(line, "secret") || !strings.Contains(line, "apikey=REDACTED") || !strings.Contains(line, "limit=2") {
+		t.Errorf("unexpected log line: %s", line)
+	}
+}
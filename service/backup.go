@@ -0,0 +1,24 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// backupHandler streams a hot copy of the database as a download
+func (c Config) backupHandler(w http.ResponseWriter, r *http.Request) {
+	name := fmt.Sprintf("pomegranate-%s.db", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+
+	_, err := c.DB.WriteBackup(w, func(size int64) {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	})
+	if err != nil {
+		// the status is already sent once the copy started, so the client only sees a truncated file
+		log.Println(fmt.Errorf("db.WriteBackup: %w", err))
+	}
+}
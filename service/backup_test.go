@@ -0,0 +1,39 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"pomegranate/database"
+	"strconv"
+	"testing"
+)
+
+func TestBackupHandler(t *testing.T) {
+	config := newTestConfig(t)
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/backup", nil)
+	req.Header.Set("X-Api-Key", key)
+	rec := httptest.NewRecorder()
+	Service(config).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d (%s)", rec.Code, rec.Body.String())
+	}
+	if length := rec.Header().Get("Content-Length"); length != strconv.Itoa(rec.Body.Len()) {
+		t.Errorf("content length %s does not match the body size %d", length, rec.Body.Len())
+	}
+
+	path := filepath.Join(t.TempDir(), "backup.db")
+	if err := os.WriteFile(path, rec.Body.Bytes(), 0600); err != nil {
+		t.Fatalf("os.WriteFile: %s", err)
+	}
+	if _, err := database.ValidateBackup(path); err != nil {
+		t.Errorf("database.ValidateBackup: %s", err)
+	}
+}
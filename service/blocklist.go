@@ -0,0 +1,36 @@
+package service
+
+import (
+	"net/http"
+)
+
+func (c Config) blocklistHandler(w http.ResponseWriter, r *http.Request) {
+	entries, err := c.Manager.Blocklist()
+	if err != nil {
+		internalError(w, "manager.Blocklist: %w", err)
+		return
+	}
+
+	if err := writeJson(w, entries); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
+
+// blocklistClearHandler removes a single release from the blocklist when a guid is given, or clears it entirely
+func (c Config) blocklistClearHandler(w http.ResponseWriter, r *http.Request) {
+	guid := r.URL.Query().Get("guid")
+
+	if guid != "" {
+		if err := c.Manager.RemoveFromBlocklist(guid); err != nil {
+			internalError(w, "manager.RemoveFromBlocklist: %w", err)
+			return
+		}
+	} else {
+		if err := c.Manager.ClearBlocklist(); err != nil {
+			internalError(w, "manager.ClearBlocklist: %w", err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
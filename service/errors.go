@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"pomegranate/database"
+	"pomegranate/manager"
+)
+
+// ErrorResponse is the body of every error returned by the /api/v1 routes
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+var errorCodes = map[int]string{
+	http.StatusBadRequest:          "bad_request",
+	http.StatusUnauthorized:        "unauthorized",
+	http.StatusNotFound:            "not_found",
+	http.StatusMethodNotAllowed:    "method_not_allowed",
+	http.StatusConflict:            "conflict",
+	http.StatusTooManyRequests:     "quota_exhausted",
+	http.StatusInternalServerError: "internal_error",
+	http.StatusBadGateway:          "bad_gateway",
+}
+
+// errorStatus maps the typed errors of the database and manager packages to http status codes
+func errorStatus(err error) int {
+	var upstreamErr *manager.UpstreamError
+
+	switch {
+	case errors.Is(err, manager.ErrInvalid), errors.Is(err, database.ErrInvalidCursor):
+		return http.StatusBadRequest
+	case errors.Is(err, database.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, manager.ErrAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, manager.ErrQuotaExhausted):
+		return http.StatusTooManyRequests
+	case errors.As(err, &upstreamErr):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeError writes the json error envelope with the given status
+func writeError(w http.ResponseWriter, status int, message string) {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := writeJson(w, ErrorResponse{Error: ErrorBody{Status: status, Code: code, Message: message}}); err != nil {
+		log.Println(err)
+	}
+}
+
+// apiError reports err to the client with the status matching its type. Unexpected errors are logged,
+// prefixed by the failed operation, and their details are not exposed.
+func apiError(w http.ResponseWriter, operation string, err error) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		log.Println(fmt.Errorf("%s: %w", operation, err))
+		writeError(w, status, "internal error")
+		return
+	}
+
+	writeError(w, status, err.Error())
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"pomegranate/manager"
+	"strings"
+	"testing"
+)
+
+func TestEventsHandler(t *testing.T) {
+	config := newTestConfig(t)
+	key, err := config.Manager.ApiKey()
+	if err != nil {
+		t.Fatalf("config.Manager.ApiKey: %s", err)
+	}
+	server := httptest.NewServer(Service(config))
+	defer server.Close()
+
+	config.Manager.Events.Publish(manager.Event{Type: manager.EventMovieAdded, ImdbId: "tt0133093"})
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/v1/events?apikey="+key, nil)
+	if err != nil {
+		t.Fatalf("http.NewRequest: %s", err)
+	}
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("http.Do: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	config.Manager.Events.Publish(manager.Event{Type: manager.EventReleaseGrabbed, ImdbId: "tt0133093", NzbID: "1"})
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reader.ReadString: %s", err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "id: 2" || lines[1] != "event: release_grabbed" || !strings.Contains(lines[2], `"nzb_id":"1"`) {
+		t.Errorf("unexpected event: %v", lines)
+	}
+
+	// closing the bus, as done on shutdown, ends the stream
+	config.Manager.Events.Close()
+	for {
+		if _, err := reader.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pomegranate/manager"
+	"strconv"
+	"time"
+)
+
+// exportHandler downloads the whole library as a json document
+func (c Config) exportHandler(w http.ResponseWriter, r *http.Request) {
+	doc, err := c.Manager.Export()
+	if err != nil {
+		apiError(w, "manager.Export", err)
+		return
+	}
+
+	name := fmt.Sprintf("pomegranate-%s.json", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	writeApiJson(w, http.StatusOK, doc)
+}
+
+// importHandler imports the json document sent as the request body. The mode parameter is either
+// merge (the default) or replace; with dry_run=true, the changes are reported but not written.
+func (c Config) importHandler(w http.ResponseWriter, r *http.Request) {
+	options := manager.ImportOptions{Mode: manager.ImportMode(r.URL.Query().Get("mode"))}
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid dry_run value")
+			return
+		}
+		options.DryRun = dryRun
+	}
+
+	var doc manager.ExportDocument
+	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid document: %s", err))
+		return
+	}
+
+	report, err := c.Manager.Import(doc, options)
+	if err != nil {
+		apiError(w, "manager.Import", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, report)
+}
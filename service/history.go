@@ -0,0 +1,48 @@
+package service
+
+import (
+	"net/http"
+	"pomegranate/models"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type HistoryResponse struct {
+	Entries    []models.HistoryEntry `json:"entries"`
+	Total      int                   `json:"total"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// writeHistory answers with a page of history, newest first, read from the limit and cursor parameters
+func (c Config) writeHistory(w http.ResponseWriter, r *http.Request, imdbId string) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid limit: "+value)
+			return
+		}
+		limit = n
+	}
+
+	entries, info, err := c.Manager.ListHistory(imdbId, limit, r.URL.Query().Get("cursor"))
+	if err != nil {
+		apiError(w, "manager.ListHistory", err)
+		return
+	}
+	if entries == nil {
+		entries = []models.HistoryEntry{}
+	}
+
+	writeApiJson(w, http.StatusOK, HistoryResponse{Entries: entries, Total: info.Total, NextCursor: info.NextCursor})
+}
+
+// historyHandler lists the events of every movie, or of the one given by the imdb_id parameter
+func (c Config) historyHandler(w http.ResponseWriter, r *http.Request) {
+	c.writeHistory(w, r, r.URL.Query().Get("imdb_id"))
+}
+
+func (c Config) apiMovieHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	c.writeHistory(w, r, chi.URLParam(r, "imdbId"))
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"net/http"
+	"pomegranate/manager"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type IndexersResponse struct {
+	Indexers []manager.IndexerHealth `json:"indexers"`
+}
+
+// indexersHandler lists the configured indexers with their health: failures, last error and next retry
+func (c Config) indexersHandler(w http.ResponseWriter, r *http.Request) {
+	indexers, err := c.Manager.IndexerHealth()
+	if err != nil {
+		apiError(w, "manager.IndexerHealth", err)
+		return
+	}
+
+	writeApiJson(w, http.StatusOK, IndexersResponse{Indexers: indexers})
+}
+
+// indexerResetHandler clears the failures of an indexer, ending its backoff
+func (c Config) indexerResetHandler(w http.ResponseWriter, r *http.Request) {
+	if err := c.Manager.ResetIndexer(chi.URLParam(r, "host")); err != nil {
+		apiError(w, "manager.ResetIndexer", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
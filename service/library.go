@@ -0,0 +1,81 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"pomegranate/database"
+	"strconv"
+)
+
+// libraryScanHandler scans the folder given by the path parameter, or the library root, for movies not yet
+// in the database. The scan runs for as long as the request, so it can be slow on large libraries.
+func (c Config) libraryScanHandler(w http.ResponseWriter, r *http.Request) {
+	root := r.URL.Query().Get("path")
+	if root == "" && c.Manager.Importer != nil {
+		root = c.Manager.Importer.Root
+	}
+	if root == "" {
+		http.Error(w, "missing path: no library is configured", http.StatusBadRequest)
+		return
+	}
+
+	result, err := c.Manager.ScanLibrary(r.Context(), c.Tmdb, root)
+	if err != nil {
+		internalError(w, "manager.ScanLibrary: %w", err)
+		return
+	}
+
+	if err := writeJson(w, result); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
+
+func (c Config) libraryPendingHandler(w http.ResponseWriter, r *http.Request) {
+	pending, err := c.Manager.PendingImports()
+	if err != nil {
+		internalError(w, "manager.PendingImports: %w", err)
+		return
+	}
+
+	if err := writeJson(w, pending); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
+
+// libraryConfirmHandler matches a pending import to the themoviedb movie given by the tmdb parameter
+func (c Config) libraryConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	tmdbID, err := strconv.ParseInt(r.URL.Query().Get("tmdb"), 10, 32)
+	if err != nil {
+		http.Error(w, "invalid tmdb id", http.StatusBadRequest)
+		return
+	}
+
+	movie, err := c.Manager.ConfirmPendingImport(c.Tmdb, id, int32(tmdbID))
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		internalError(w, "manager.ConfirmPendingImport: %w", err)
+		return
+	}
+
+	if err := writeJson(w, movie); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
+
+func (c Config) libraryDismissHandler(w http.ResponseWriter, r *http.Request) {
+	err := c.Manager.DismissPendingImport(r.URL.Query().Get("id"))
+	if errors.Is(err, database.ErrNotFound) {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		internalError(w, "manager.DismissPendingImport: %w", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,17 +2,22 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/lsmoura/humantoken"
 	"log"
 	"net/http"
+	"pomegranate/database"
+	"pomegranate/manager"
 	"pomegranate/models"
+	"strconv"
+	"strings"
 )
 
 type MovieAddResponse struct {
-	Message  string `json:"message"`
-	Title    string `json:"title"`
-	Overview string `json:"overview"`
+	Message  string                       `json:"message"`
+	Title    string                       `json:"title"`
+	Overview string                       `json:"overview"`
+	Indexers []models.IndexerSearchResult `json:"indexers,omitempty"` // Outcome of the release search on each indexer
 }
 
 func (c Config) movieSearchHandler(w http.ResponseWriter, r *http.Request) {
@@ -42,91 +47,147 @@ func (c Config) movieSearchHandler(w http.ResponseWriter, r *http.Request) {
 
 func (c Config) movieAddHandler(w http.ResponseWriter, r *http.Request) {
 	identifier := r.URL.Query().Get("identifier")
+	profileID := r.URL.Query().Get("profile")
 
-	// TODO: Validate that the identifier is in the format tt0000000...
-	movie, err := c.Tmdb.ReadSingleMovie(identifier)
+	dbMovie, err := c.Manager.SaveMovie(c.Tmdb, identifier, profileID, true)
 	if err != nil {
-		internalError(w, "tmdb.ReadSingleMovie (%s): %w", identifier, err)
+		internalError(w, "manager.SaveMovie (%s): %w", identifier, err)
 		return
 	}
 
-	dbMovie, err := c.Manager.Movie(identifier)
+	response := MovieAddResponse{
+		Message:  "Movie added",
+		Title:    dbMovie.Title,
+		Overview: dbMovie.Overview,
+	}
+	if dbMovie.LastSearch != nil {
+		response.Indexers = dbMovie.LastSearch.Indexers
+		if dbMovie.LastSearch.Failed() {
+			response.Message = "Movie added, but the release search failed"
+		}
+	}
+	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		internalError(w, "DB.Movie (%s): %w", identifier, err)
+		internalError(w, "json.Marshal: %w", err)
 		return
 	}
+	w.Header().Add("Content-Type", "application/json")
+	if _, err := w.Write([]byte(responseBytes)); err != nil {
+		log.Println(fmt.Errorf("http.ResponseWriter.Write: %w", err))
+	}
+}
 
-	dbMovie.ImdbId = movie.ImdbId
-	dbMovie.Title = movie.Title
-	dbMovie.ReleaseDate = movie.ReleaseDate
-	dbMovie.Overview = movie.Overview
+type MovieListResponse struct {
+	Movies     []models.Movie `json:"movies"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
 
-	for _, n := range c.Newz {
-		parsedIdentifier := identifier[2:]
+// movieSortFields maps the sort parameter values to movie fields
+var movieSortFields = map[string]string{
+	"imdb_id":      "ImdbId",
+	"title":        "Title",
+	"release_date": "ReleaseDate",
+	"runtime":      "Runtime",
+}
 
-		items, err := n.SearchImdb(parsedIdentifier)
-		if err != nil {
-			internalError(w, "newznab.SearchImdb: %w", err)
-			return
+// movieListQuery reads the sort, limit, offset and cursor parameters of a movie list request.
+// Sorting is descending when the sort field starts with a dash, as in ?sort=-release_date.
+func movieListQuery(r *http.Request) (database.Query, error) {
+	var query database.Query
+	params := r.URL.Query()
+
+	if sortParam := params.Get("sort"); sortParam != "" {
+		name := strings.TrimPrefix(sortParam, "-")
+		field, ok := movieSortFields[name]
+		if !ok {
+			return query, fmt.Errorf("cannot sort by %s", name)
 		}
+		query.Sort = field
+		query.Descending = strings.HasPrefix(sortParam, "-")
+	}
 
-		fmt.Println(items)
-
-		for _, item := range items {
-			found := false
-			for _, nzb := range dbMovie.NzbInfo {
-				if nzb.URL == item.URL {
-					found = true
-				}
-			}
-			if !found {
-				dbMovie.NzbInfo = append(dbMovie.NzbInfo, models.NzbInfo{
-					ID:     humantoken.Generate(8, nil),
-					Title:  item.Title,
-					GUID:   item.GUID,
-					URL:    item.URL,
-					Status: models.StatusUnknown,
-					Size:   item.Size,
-				})
-			}
+	for param, dst := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		value := params.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return query, fmt.Errorf("invalid %s: %s", param, value)
 		}
+		*dst = n
 	}
+	query.Cursor = params.Get("cursor")
 
-	if err := dbMovie.Store(c.DB); err != nil {
-		internalError(w, "database.Movie.Store: %w", err)
+	return query, nil
+}
+
+// nextPageURI returns the uri of the page of a list request starting at the given cursor
+func nextPageURI(r *http.Request, cursor string) string {
+	params := r.URL.Query()
+	params.Del("apikey")
+	params.Del("offset")
+	params.Set("cursor", cursor)
+
+	return r.URL.Path + "?" + params.Encode()
+}
+
+// movieListHandler returns the movies as a bare array. The total count is sent in the X-Total-Count
+// header, and the next page, if any, in the Link header.
+func (c Config) movieListHandler(w http.ResponseWriter, r *http.Request) {
+	query, err := movieListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	response := MovieAddResponse{
-		Message:  "Movie added",
-		Title:    dbMovie.Title,
-		Overview: dbMovie.Overview,
+	movies, info, err := c.Manager.ListMovies(query)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		http.Error(w, "invalid cursor", http.StatusBadRequest)
+		return
 	}
-	responseBytes, err := json.Marshal(response)
 	if err != nil {
-		internalError(w, "json.Marshal: %w", err)
+		internalError(w, "manager.ListMovies: %w", err)
 		return
 	}
-	w.Header().Add("Content-Type", "application/json")
-	if _, err := w.Write([]byte(responseBytes)); err != nil {
-		log.Println(fmt.Errorf("http.ResponseWriter.Write: %w", err))
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(info.Total))
+	if info.NextCursor != "" {
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, nextPageURI(r, info.NextCursor)))
+	}
+	if err := writeJson(w, movies); err != nil {
+		internalError(w, "writeJson: %w", err)
 	}
 }
 
-func (c Config) movieListHandler(w http.ResponseWriter, r *http.Request) {
-	movies, err := c.Manager.AllMovies()
+type MovieReleasesResponse struct {
+	ImdbId    string                      `json:"imdb_id"`
+	ProfileID string                      `json:"profile_id"`
+	Releases  []manager.ReleaseEvaluation `json:"releases"`
+}
+
+func (c Config) movieReleasesHandler(w http.ResponseWriter, r *http.Request) {
+	identifier := r.URL.Query().Get("identifier")
+
+	movie, err := c.Manager.Movie(identifier)
 	if err != nil {
-		internalError(w, "DB.AllMovies: %w", err)
+		internalError(w, "manager.Movie (%s): %w", identifier, err)
 		return
 	}
 
-	moviesBytes, err := json.Marshal(movies)
+	releases, err := c.Manager.MovieReleases(movie)
 	if err != nil {
-		internalError(w, "json.Marshal: %w", err)
+		internalError(w, "manager.MovieReleases: %w", err)
 		return
 	}
-	w.Header().Add("Content-Type", "application/json")
-	if _, err := w.Write([]byte(moviesBytes)); err != nil {
-		log.Println(fmt.Errorf("http.ResponseWriter.Write: %w", err))
+
+	response := MovieReleasesResponse{
+		ImdbId:    movie.ImdbId,
+		ProfileID: movie.ProfileID,
+		Releases:  releases,
+	}
+	if err := writeJson(w, response); err != nil {
+		internalError(w, "writeJson: %w", err)
 	}
 }
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"pomegranate/models"
+)
+
+func (c Config) profileListHandler(w http.ResponseWriter, r *http.Request) {
+	profiles, err := c.Manager.AllProfiles()
+	if err != nil {
+		internalError(w, "manager.AllProfiles: %w", err)
+		return
+	}
+
+	if err := writeJson(w, profiles); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
+
+func (c Config) profileSaveHandler(w http.ResponseWriter, r *http.Request) {
+	var profile models.QualityProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, fmt.Sprintf("invalid profile: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	profile, err := c.Manager.SaveProfile(profile)
+	if err != nil {
+		internalError(w, "manager.SaveProfile: %w", err)
+		return
+	}
+
+	if err := writeJson(w, profile); err != nil {
+		internalError(w, "writeJson: %w", err)
+	}
+}
@@ -6,20 +6,20 @@ import (
 	"log"
 	"net/http"
 	"pomegranate/database"
+	"pomegranate/downloader"
 	"pomegranate/manager"
+	"pomegranate/metrics"
 	"pomegranate/newznab"
-	"pomegranate/sabnzbd"
 	"pomegranate/themoviedb"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 type Config struct {
-	DB      *database.DB
-	Newz    []newznab.Newznab
-	Sabnzbd sabnzbd.Sabnzbd
-	Tmdb    themoviedb.Themoviedb
+	DB         *database.DB
+	Newz       []newznab.Newznab
+	Downloader downloader.Downloader
+	Tmdb       themoviedb.Themoviedb
 
 	Manager *manager.Manager
 }
@@ -53,18 +53,40 @@ func writeJson(w http.ResponseWriter, data interface{}) error {
 
 func Service(config Config) http.Handler {
 	r := chi.NewRouter()
-	r.Use(middleware.Logger)
+	r.Use(requestLogger)
+	r.Use(metrics.Middleware)
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte("pomegranate"))
 		if err != nil {
 			log.Println(fmt.Errorf("http.ResponseWriter.Write: %w", err))
 		}
 	})
-	r.Get("/movie/search", config.movieSearchHandler)
-	r.Get("/movie/add", config.movieAddHandler)
-	r.Get("/movie/list", config.movieListHandler)
+	// everything but the health route above requires the api key
+	r.Group(func(r chi.Router) {
+		r.Use(config.requireApiKey)
 
-	r.Get("/nzb/download", config.nzbDownload)
+		r.Mount("/api/v1", config.apiRouter())
+		// scrapers send the key as the apikey parameter
+		r.Handle("/metrics", metrics.Handler())
+
+		r.Get("/movie/search", config.movieSearchHandler)
+		r.Get("/movie/add", config.movieAddHandler)
+		r.Get("/movie/list", config.movieListHandler)
+		r.Get("/movie/releases", config.movieReleasesHandler)
+
+		r.Get("/profile/list", config.profileListHandler)
+		r.Post("/profile/save", config.profileSaveHandler)
+
+		r.Get("/nzb/download", config.nzbDownload)
+
+		r.Get("/blocklist/list", config.blocklistHandler)
+		r.Post("/blocklist/clear", config.blocklistClearHandler)
+
+		r.Post("/library/scan", config.libraryScanHandler)
+		r.Get("/library/pending", config.libraryPendingHandler)
+		r.Post("/library/confirm", config.libraryConfirmHandler)
+		r.Post("/library/dismiss", config.libraryDismissHandler)
+	})
 
 	return r
 }
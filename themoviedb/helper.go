@@ -1,14 +1,25 @@
 package themoviedb
 
+import "net/http"
+
 type Themoviedb struct {
 	apiKey string
 	Logger Logger
+	Client *http.Client // http.DefaultClient when nil
 }
 
 type Logger interface {
 	Log(serviceName string, format string, a ...interface{})
 }
 
+func (d Themoviedb) httpClient() *http.Client {
+	if d.Client == nil {
+		return http.DefaultClient
+	}
+
+	return d.Client
+}
+
 func (d Themoviedb) log(format string, a ...interface{}) {
 	if d.Logger == nil {
 		return
@@ -24,9 +35,9 @@ func New(apiKey string) Themoviedb {
 }
 
 func (d Themoviedb) ReadSingleMovie(key string) (SingleMovieResponse, error) {
-	return ReadSingleMovie(d.apiKey, key, d.log)
+	return ReadSingleMovie(d.httpClient(), d.apiKey, key, d.log)
 }
 
 func (d Themoviedb) ReadMovies(search string, page int) (Response, error) {
-	return ReadMovies(d.apiKey, search, page, d.log)
+	return ReadMovies(d.httpClient(), d.apiKey, search, page, d.log)
 }
@@ -11,14 +11,14 @@ import (
 
 // ReadSingleMovie takes a movie key as parameter.
 // the key parameter can be either a themoviedb id or an imdb id (which beging with tt)
-func ReadSingleMovie(apiKey string, key string, logger func(string, ...interface{})) (SingleMovieResponse, error) {
+func ReadSingleMovie(client *http.Client, apiKey string, key string, logger func(string, ...interface{})) (SingleMovieResponse, error) {
 	movieInfo := SingleMovieResponse{}
 	url := fmt.Sprintf("https://api.themoviedb.org/3/movie/%s?api_key=%s&append_to_response=alternative_titles&language=en", key, apiKey)
 
 	logger(fmt.Sprintf("HTTP request: %s\n", strings.ReplaceAll(url, apiKey, "xxx")))
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		return movieInfo, fmt.Errorf("http.Get: %w", err)
+		return movieInfo, fmt.Errorf("client.Get: %w", err)
 	}
 
 	defer func(Body io.ReadCloser) {
@@ -40,7 +40,7 @@ func ReadSingleMovie(apiKey string, key string, logger func(string, ...interface
 	return movieInfo, nil
 }
 
-func ReadMovies(apiKey string, search string, page int, logger func(string, ...interface{})) (Response, error) {
+func ReadMovies(client *http.Client, apiKey string, search string, page int, logger func(string, ...interface{})) (Response, error) {
 	ThemoviedbResponse := Response{}
 
 	url := fmt.Sprintf("https://api.themoviedb.org/3/search/movie?api_key=%s&search_type=ngram&query=%s", apiKey, search)
@@ -49,9 +49,9 @@ func ReadMovies(apiKey string, search string, page int, logger func(string, ...i
 	}
 
 	logger(fmt.Sprintf("HTTP request: %s\n", strings.ReplaceAll(url, apiKey, "xxx")))
-	resp, err := http.Get(url)
+	resp, err := client.Get(url)
 	if err != nil {
-		return ThemoviedbResponse, fmt.Errorf("http.Get: %w", err)
+		return ThemoviedbResponse, fmt.Errorf("client.Get: %w", err)
 	}
 
 	defer func(Body io.ReadCloser) {